@@ -0,0 +1,10 @@
+package charts
+
+import (
+	_ "embed"
+)
+
+// ImagesYAML contains the content of the images.yaml file
+//
+//go:embed images.yaml
+var ImagesYAML string
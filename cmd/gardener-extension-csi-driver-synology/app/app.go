@@ -32,6 +32,7 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 	}
 
 	options.optionAggregator.AddFlags(cmd.Flags())
+	cmd.AddCommand(NewRenderCommand(), NewDoctorCommand())
 
 	return cmd
 }
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	csidriversynologycmd "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/cmd"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/doctor"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type doctorOptions struct {
+	configOptions csidriversynologycmd.ConfigOptions
+	adminSecrets  []string
+	minFreeSpace  string
+	timeout       time.Duration
+	output        string
+}
+
+// NewDoctorCommand creates a new command checking whether all NAS of the configuration meet the requirements of the
+// extension
+func NewDoctorCommand() *cobra.Command {
+	options := &doctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check whether all NAS of the configuration meet the requirements of the extension",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.configOptions.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+
+			if options.output != "text" && options.output != "json" {
+				return fmt.Errorf("unsupported output format %q, must be text or json", options.output)
+			}
+
+			cmd.SilenceUsage = true
+
+			return options.run(cmd)
+		},
+	}
+
+	options.configOptions.AddFlags(cmd.Flags())
+	cmd.Flags().StringArrayVar(&options.adminSecrets, "admin-secret", nil, "Path to the Secret manifest with the DSM admin credentials of a secretRef of the configuration, as <secretRef>=<path> or <path> for the secretRef of the synology configuration, may be repeated")
+	cmd.Flags().StringVar(&options.minFreeSpace, "min-free-space", "10Gi", "Free space the volume of each NAS needs at least")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 5*time.Second, "Timeout of the connection to the iSCSI portal")
+	cmd.Flags().StringVarP(&options.output, "output", "o", "text", "Output format of the report, text or json")
+	_ = cmd.MarkFlagRequired("admin-secret")
+
+	return cmd
+}
+
+func (o *doctorOptions) run(cmd *cobra.Command) error {
+	cfg := config.ControllerConfiguration{}
+	o.configOptions.Completed().Apply(&cfg)
+
+	minFreeSpace, err := resource.ParseQuantity(o.minFreeSpace)
+	if err != nil {
+		return fmt.Errorf("invalid minimum free space: %w", err)
+	}
+
+	secretPaths, err := o.adminSecretPaths(cfg.SynologyConfig.SecretRef)
+	if err != nil {
+		return err
+	}
+
+	// read all credentials before checking any NAS
+	var checks []doctor.Options
+	for _, n := range doctorNAS(cfg.SynologyConfig) {
+		secretPath, ok := secretPaths[n.SecretRef]
+		if !ok {
+			return fmt.Errorf("no admin secret given for secretRef %q of %s", n.SecretRef, n.URL)
+		}
+
+		username, password, err := adminCredentials(secretPath)
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, doctor.Options{
+			NAS:          n.Name,
+			URL:          n.URL,
+			Username:     username,
+			Password:     password,
+			Volume:       n.Location,
+			MinFreeSpace: minFreeSpace,
+			DialTimeout:  o.timeout,
+		})
+	}
+
+	var (
+		reports []*doctor.Report
+		passed  = true
+	)
+	for _, opts := range checks {
+		report := doctor.Run(cmd.Context(), opts)
+		reports = append(reports, report)
+		passed = passed && report.Passed
+	}
+
+	if o.output == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(reports)
+	} else {
+		for i, report := range reports {
+			if i > 0 {
+				if _, err = fmt.Fprintln(cmd.OutOrStdout()); err != nil {
+					break
+				}
+			}
+			if err = doctor.WriteText(cmd.OutOrStdout(), report); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if !passed {
+		return errors.New("preflight checks failed")
+	}
+
+	return nil
+}
+
+// doctorNAS returns the NAS of the synology configuration followed by the further NAS, each with the DSM volume its
+// StorageClass provisions the LUNs on
+func doctorNAS(cfg config.SynologyConfiguration) []config.SynologyNAS {
+	all := []config.SynologyNAS{{URL: cfg.URL, SecretRef: cfg.SecretRef, Location: constants.DefaultVolumeLocation}}
+	for _, n := range cfg.AdditionalNAS {
+		if n.Location == "" {
+			n.Location = constants.DefaultVolumeLocation
+		}
+		all = append(all, n)
+	}
+	return all
+}
+
+// adminSecretPaths returns the paths of the admin Secret manifests keyed by secretRef, a path without secretRef
+// belongs to the given secretRef of the synology configuration
+func (o *doctorOptions) adminSecretPaths(defaultSecretRef string) (map[string]string, error) {
+	if len(o.adminSecrets) == 0 {
+		return nil, errors.New("no admin secret given")
+	}
+
+	paths := make(map[string]string, len(o.adminSecrets))
+	for _, value := range o.adminSecrets {
+		secretRef, path, ok := strings.Cut(value, "=")
+		if !ok {
+			secretRef, path = defaultSecretRef, value
+		}
+		if secretRef == "" || path == "" {
+			return nil, fmt.Errorf("invalid admin secret %q, must be <secretRef>=<path> or <path>", value)
+		}
+		if _, exists := paths[secretRef]; exists {
+			return nil, fmt.Errorf("admin secret for secretRef %q given more than once", secretRef)
+		}
+		paths[secretRef] = path
+	}
+
+	return paths, nil
+}
+
+// adminCredentials reads the DSM admin credentials from the Secret manifest, from its data or string data
+func adminCredentials(path string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read admin secret %s: %w", path, err)
+	}
+
+	secret := &corev1.Secret{}
+	if _, _, err := kubernetes.SeedCodec.UniversalDeserializer().Decode(data, nil, secret); err != nil {
+		return "", "", fmt.Errorf("failed to decode admin secret %s: %w", path, err)
+	}
+
+	value := func(key string) (string, error) {
+		if v, ok := secret.Data[key]; ok {
+			return string(v), nil
+		}
+		if v, ok := secret.StringData[key]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("admin secret %s does not contain %q", path, key)
+	}
+
+	username, err := value(constants.SynologySecretAdminUserRef)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := value(constants.SynologySecretAdminPasswordRef)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
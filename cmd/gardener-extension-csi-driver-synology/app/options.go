@@ -12,6 +12,7 @@ import (
 	"github.com/gardener/gardener/extensions/pkg/util"
 	"github.com/gardener/gardener/pkg/apis/authentication/install"
 	"github.com/labstack/gommon/log"
+	csidriversynologyinstall "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/install"
 	csidriversynologycmd "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/cmd"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,6 +22,7 @@ import (
 	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
 	controllercmd "github.com/gardener/gardener/extensions/pkg/controller/cmd"
 	heartbeatcontroller "github.com/gardener/gardener/extensions/pkg/controller/heartbeat"
+	webhookcmd "github.com/gardener/gardener/extensions/pkg/webhook/cmd"
 	ghealth "github.com/gardener/gardener/pkg/healthz"
 
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/controller/healthcheck"
@@ -37,6 +39,7 @@ type Options struct {
 	healthOptions            *controllercmd.ControllerOptions
 	controllerSwitches       *controllercmd.SwitchOptions
 	reconcileOptions         *controllercmd.ReconcilerOptions
+	webhookOptions           *webhookcmd.AddToManagerOptions
 	optionAggregator         controllercmd.OptionAggregator
 }
 
@@ -51,6 +54,8 @@ func NewOptions() *Options {
 			LeaderElectionNamespace: os.Getenv("LEADER_ELECTION_NAMESPACE"),
 			MetricsBindAddress:      ":8080",
 			HealthBindAddress:       ":8081",
+			WebhookServerPort:       10250,
+			WebhookCertDir:          "/tmp/gardener-extensions-cert",
 		},
 
 		// options for the controlplane controller
@@ -70,6 +75,15 @@ func NewOptions() *Options {
 		},
 		controllerSwitches: csidriversynologycmd.ControllerSwitchOptions(),
 		reconcileOptions:   &controllercmd.ReconcilerOptions{},
+		webhookOptions: webhookcmd.NewAddToManagerOptions(
+			constants.ExtensionName,
+			"",
+			nil,
+			&webhookcmd.ServerOptions{
+				Namespace: os.Getenv("WEBHOOK_CONFIG_NAMESPACE"),
+			},
+			csidriversynologycmd.WebhookSwitchOptions(),
+		),
 	}
 
 	options.optionAggregator = controllercmd.NewOptionAggregator(
@@ -82,6 +96,7 @@ func NewOptions() *Options {
 		controllercmd.PrefixOption("healthcheck-", options.healthOptions),
 		options.controllerSwitches,
 		options.reconcileOptions,
+		options.webhookOptions,
 	)
 	return options
 }
@@ -127,8 +142,15 @@ func (options *Options) run(ctx context.Context) error {
 	}
 	log.Info("added mgr-scheme to installation")
 
+	err = csidriversynologyinstall.AddToScheme(mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("could not add csi-driver-synology api to mgr-scheme: %w", err)
+	}
+	log.Info("added csi-driver-synology api to mgr-scheme")
+
 	ctrlConfig := options.csidriversynologyOptions.Completed()
 	ctrlConfig.Apply(&lifecycle.DefaultAddOptions.Config)
+	lifecycle.DefaultAddOptions.UserCleanupNamespace = os.Getenv("LEADER_ELECTION_NAMESPACE")
 
 	options.controllerOptions.Completed().Apply(&lifecycle.DefaultAddOptions.ControllerOptions)
 	options.reconcileOptions.Completed().Apply(&lifecycle.DefaultAddOptions.IgnoreOperationAnnotation, &lifecycle.DefaultAddOptions.ExtensionClass)
@@ -139,6 +161,11 @@ func (options *Options) run(ctx context.Context) error {
 	}
 	log.Info("added controllers to manager")
 
+	if _, err := options.webhookOptions.Completed().AddToManager(ctx, mgr, nil, false); err != nil {
+		return fmt.Errorf("could not add webhooks to manager: %w", err)
+	}
+	log.Info("added webhooks to manager")
+
 	if err := mgr.AddReadyzCheck("informer-sync", ghealth.NewCacheSyncHealthz(mgr.GetCache())); err != nil {
 		return fmt.Errorf("could not add ready check for informers: %w", err)
 	}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	csidriversynologyinstall "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/install"
+	csidriversynologycmd "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/cmd"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/controller/lifecycle"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	runtimelog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type renderOptions struct {
+	configOptions      csidriversynologycmd.ConfigOptions
+	providerConfigPath string
+	shootName          string
+	kubernetesVersion  string
+	resolveDSMHosts    bool
+}
+
+// NewRenderCommand creates a new command printing the manifests the extension deploys for a shoot
+func NewRenderCommand() *cobra.Command {
+	options := &renderOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Print the manifests of the ManagedResources deployed for a shoot without accessing any cluster or the NAS",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.configOptions.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+
+			cmd.SilenceUsage = true
+
+			return options.run(cmd)
+		},
+	}
+
+	options.configOptions.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&options.providerConfigPath, "provider-config", "", "Path to the providerConfig of the shoot's extension, a CsiDriverSynologyConfig")
+	cmd.Flags().StringVar(&options.shootName, "shoot-name", "", "Technical name of the shoot, i.e. its control plane namespace in the seed, e.g. shoot--project--name")
+	cmd.Flags().StringVar(&options.kubernetesVersion, "kubernetes-version", "1.33.0", "Kubernetes version of the shoot, used to select the images")
+	cmd.Flags().BoolVar(&options.resolveDSMHosts, "resolve-dsm-hosts", false, "Resolve the DSM hostnames for the egress NetworkPolicy, the DSM ports are opened to any destination for hostnames otherwise")
+	_ = cmd.MarkFlagRequired("shoot-name")
+
+	return cmd
+}
+
+func (o *renderOptions) run(cmd *cobra.Command) error {
+	cfg := config.ControllerConfiguration{}
+	o.configOptions.Completed().Apply(&cfg)
+
+	shootConfig, err := o.providerConfig()
+	if err != nil {
+		return err
+	}
+
+	// worker pools are taken as given, the manifests do not depend on the pools' specification
+	var workers []gardencorev1beta1.Worker
+	if shootConfig.NodePlugin != nil {
+		for _, pool := range shootConfig.NodePlugin.WorkerPools {
+			workers = append(workers, gardencorev1beta1.Worker{Name: pool})
+		}
+	}
+
+	cluster := &extensions.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: o.shootName},
+		Shoot: &gardencorev1beta1.Shoot{
+			Spec: gardencorev1beta1.ShootSpec{
+				Kubernetes: gardencorev1beta1.Kubernetes{Version: o.kubernetesVersion},
+				Provider:   gardencorev1beta1.Provider{Workers: workers},
+			},
+		},
+	}
+
+	var resolver synology.Resolver
+	if o.resolveDSMHosts {
+		resolver = net.DefaultResolver
+	}
+
+	rendered, err := lifecycle.Render(cmd.Context(), runtimelog.Log, cfg, cluster, shootConfig, resolver)
+	if err != nil {
+		return err
+	}
+
+	return writeManagedResources(cmd.OutOrStdout(), rendered)
+}
+
+// providerConfig decodes the provider config file, an empty config is used if no file is given
+func (o *renderOptions) providerConfig() (*csidriversynology.CsiDriverSynologyConfig, error) {
+	shootConfig := &csidriversynology.CsiDriverSynologyConfig{}
+	if o.providerConfigPath == "" {
+		return shootConfig, nil
+	}
+
+	data, err := os.ReadFile(o.providerConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider config: %w", err)
+	}
+
+	scheme := runtime.NewScheme()
+	csidriversynologyinstall.Install(scheme)
+
+	if _, _, err := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDecoder().Decode(data, nil, shootConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode provider config: %w", err)
+	}
+
+	return shootConfig, nil
+}
+
+// writeManagedResources writes the objects of the given ManagedResources as a multi-document YAML
+func writeManagedResources(w io.Writer, rendered []lifecycle.RenderedManagedResource) error {
+	for _, mr := range rendered {
+		if _, err := fmt.Fprintf(w, "---\n# ManagedResource %s/%s\n%s", mr.Namespace, mr.Name, mr.Manifests); err != nil {
+			return err
+		}
+	}
+	return nil
+}
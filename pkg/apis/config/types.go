@@ -2,6 +2,7 @@ package config
 
 import (
 	apisconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,14 +15,63 @@ type ControllerConfiguration struct {
 	// Synology holds the Synology-specific configuration block ("synology:" im YAML)
 	SynologyConfig SynologyConfiguration
 
+	// ControllerInSeed deploys the CSI controller plugin into the shoot's control plane namespace in the seed
+	// instead of the shoot's kube-system namespace. Only the node plugin is deployed into the shoot then.
+	ControllerInSeed bool
+
+	// ControllerReplicas is the number of CSI controller replicas. Defaults to 2 for shoots with a highly available
+	// control plane and to 1 otherwise.
+	ControllerReplicas *int32
+
+	// Images overwrites images of the image vector embedded in the extension
+	Images []ImageOverwrite
+
+	// Resources overwrites the resource requirements of the CSI containers
+	Resources *WorkloadResources
+
+	// AdditionalEgressCIDRs are CIDRs the CSI pods in the shoot may reach on any port in addition to the DSM hosts,
+	// e.g. further iSCSI portals of the NAS
+	AdditionalEgressCIDRs []string
+
+	// CapacityRefreshInterval is the interval the usage of the DSM volumes is published as CSIStorageCapacity objects
+	// in the shoots, capacity tracking is disabled if unset
+	CapacityRefreshInterval *metav1.Duration
+
+	// DisableUserWhileHibernated expires the DSM user of a shoot while the shoot is hibernated, so that its
+	// credentials cannot be used to access the NAS. The user is enabled again when the shoot wakes up.
+	DisableUserWhileHibernated bool
+
 	// HealthCheckConfig is the config for the health check controller
 	HealthCheckConfig *apisconfigv1alpha1.HealthCheckConfig
 }
 
+// ImageOverwrite overwrites an image of the image vector
+type ImageOverwrite struct {
+	// Name is the name of the image in the image vector, e.g. csi-provisioner
+	Name string
+	// Repository is the repository the image is pulled from
+	Repository *string
+	// Tag is the tag of the image
+	Tag *string
+}
+
+// WorkloadResources contains the resource requirements of the CSI workloads, keyed by container name
+type WorkloadResources struct {
+	// Controller contains the resource requirements of the CSI controller containers
+	Controller map[string]corev1.ResourceRequirements
+	// Node contains the resource requirements of the CSI node containers
+	Node map[string]corev1.ResourceRequirements
+}
+
 type SynologyConfiguration struct {
 	URL            string
 	SecretRef      string
 	StorageClasses SynologyStorageClasses
+	SnapshotClass  SynologySnapshotClass
+	Topology       *SynologyTopology
+	AdditionalNAS  []SynologyNAS
+	RateLimit      *SynologyRateLimit
+	Retry          *SynologyRetry
 }
 
 type SynologyStorageClasses struct {
@@ -31,3 +81,54 @@ type SynologyStorageClasses struct {
 type ISCSIStorageClass struct {
 	Parameters map[string]string
 }
+
+// SynologyTopology restricts volumes of the NAS to the nodes which can reach it
+type SynologyTopology struct {
+	// Key is the node label describing the location of a node. Defaults to topology.kubernetes.io/zone.
+	Key string
+	// Values are the values of the node label of the nodes which can reach the NAS, e.g. zones
+	Values []string
+}
+
+// SynologyNAS is a further NAS the volumes of the shoots can be provisioned on, it gets a StorageClass of its own
+type SynologyNAS struct {
+	// Name identifies the NAS, its StorageClass is named synology-iscsi-<name>
+	Name string
+	// URL is the URL of the DSM API of the NAS
+	URL string
+	// SecretRef is the name of the shoot resource referencing the admin credentials of the NAS
+	SecretRef string
+	// Location is the DSM volume the LUNs are created on. Defaults to /volume1.
+	Location string
+	// Topology restricts volumes of the NAS to the nodes which can reach it
+	Topology *SynologyTopology
+}
+
+// SynologyRateLimit limits the requests of the extension to the DSM API of the NAS across all shoots
+type SynologyRateLimit struct {
+	// RequestsPerSecond is the sustained rate of requests. Defaults to 5.
+	RequestsPerSecond *int32
+	// Burst is the number of requests which may exceed the rate at once. Defaults to 10.
+	Burst *int32
+	// MaxConcurrent is the maximum number of requests in flight. Defaults to 4.
+	MaxConcurrent *int32
+}
+
+// SynologyRetry configures the retries of requests to the DSM API which failed because of transport errors,
+// timeouts or 5xx responses
+type SynologyRetry struct {
+	// MaxRetries is the number of retries of a request, 0 disables retries. Defaults to 3.
+	MaxRetries *int32
+	// InitialBackoff is the wait before the first retry, it doubles with each further retry. Defaults to 500ms.
+	InitialBackoff *metav1.Duration
+	// MaxBackoff caps the wait between retries. Defaults to 10s.
+	MaxBackoff *metav1.Duration
+}
+
+// SynologySnapshotClass configures the VolumeSnapshotClass deployed into the shoot
+type SynologySnapshotClass struct {
+	// DeletionPolicy is the deletion policy of the VolumeSnapshotClass, either Delete or Retain. Defaults to Delete.
+	DeletionPolicy string
+	// Parameters are the DSM snapshot parameters, e.g. description and is_locked
+	Parameters map[string]string
+}
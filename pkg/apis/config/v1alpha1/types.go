@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	apisconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,17 +15,92 @@ type ControllerConfiguration struct {
 	// Synology holds the Synology-specific configuration
 	SynologyConfig SynologyConfiguration `json:"synology"`
 
+	// ControllerInSeed deploys the CSI controller plugin into the shoot's control plane namespace in the seed
+	// instead of the shoot's kube-system namespace. Only the node plugin is deployed into the shoot then.
+	// +optional
+	ControllerInSeed bool `json:"controllerInSeed,omitempty"`
+
+	// ControllerReplicas is the number of CSI controller replicas. Defaults to 2 for shoots with a highly available
+	// control plane and to 1 otherwise.
+	// +optional
+	ControllerReplicas *int32 `json:"controllerReplicas,omitempty"`
+
+	// Images overwrites images of the image vector embedded in the extension
+	// +optional
+	Images []ImageOverwrite `json:"images,omitempty"`
+
+	// Resources overwrites the resource requirements of the CSI containers
+	// +optional
+	Resources *WorkloadResources `json:"resources,omitempty"`
+
+	// AdditionalEgressCIDRs are CIDRs the CSI pods in the shoot may reach on any port in addition to the DSM hosts,
+	// e.g. further iSCSI portals of the NAS
+	// +optional
+	AdditionalEgressCIDRs []string `json:"additionalEgressCIDRs,omitempty"`
+
+	// CapacityRefreshInterval is the interval the usage of the DSM volumes is published as CSIStorageCapacity objects
+	// in the shoots, capacity tracking is disabled if unset
+	// +optional
+	CapacityRefreshInterval *metav1.Duration `json:"capacityRefreshInterval,omitempty"`
+
+	// DisableUserWhileHibernated expires the DSM user of a shoot while the shoot is hibernated, so that its
+	// credentials cannot be used to access the NAS. The user is enabled again when the shoot wakes up.
+	// +optional
+	DisableUserWhileHibernated bool `json:"disableUserWhileHibernated,omitempty"`
+
 	// HealthCheckConfig is the config for the health check controller
 	// +optional
 	HealthCheckConfig *apisconfigv1alpha1.HealthCheckConfig `json:"healthCheckConfig,omitempty"`
 }
 
+// ImageOverwrite overwrites an image of the image vector
+type ImageOverwrite struct {
+	// Name is the name of the image in the image vector, e.g. csi-provisioner
+	Name string `json:"name"`
+	// Repository is the repository the image is pulled from
+	// +optional
+	Repository *string `json:"repository,omitempty"`
+	// Tag is the tag of the image
+	// +optional
+	Tag *string `json:"tag,omitempty"`
+}
+
+// WorkloadResources contains the resource requirements of the CSI workloads, keyed by container name
+type WorkloadResources struct {
+	// Controller contains the resource requirements of the CSI controller containers
+	// +optional
+	Controller map[string]corev1.ResourceRequirements `json:"controller,omitempty"`
+	// Node contains the resource requirements of the CSI node containers
+	// +optional
+	Node map[string]corev1.ResourceRequirements `json:"node,omitempty"`
+}
+
 type SynologyConfiguration struct {
 	URL       string `json:"url"`
 	SecretRef string `json:"secretRef"`
 
 	// StorageClasses defines storage class configuration
 	StorageClasses SynologyStorageClasses `json:"storageClasses"`
+
+	// SnapshotClass defines the volume snapshot class configuration
+	// +optional
+	SnapshotClass SynologySnapshotClass `json:"snapshotClass,omitempty"`
+
+	// Topology restricts volumes of the NAS to the nodes which can reach it
+	// +optional
+	Topology *SynologyTopology `json:"topology,omitempty"`
+
+	// AdditionalNAS are further NAS the volumes of the shoots can be provisioned on
+	// +optional
+	AdditionalNAS []SynologyNAS `json:"additionalNAS,omitempty"`
+
+	// RateLimit limits the requests to the DSM API of the NAS
+	// +optional
+	RateLimit *SynologyRateLimit `json:"rateLimit,omitempty"`
+
+	// Retry configures the retries of requests to the DSM API which failed transiently
+	// +optional
+	Retry *SynologyRetry `json:"retry,omitempty"`
 }
 
 type SynologyStorageClasses struct {
@@ -34,3 +110,65 @@ type SynologyStorageClasses struct {
 type ISCSIStorageClass struct {
 	Parameters map[string]string `json:"parameters"`
 }
+
+// SynologyTopology restricts volumes of the NAS to the nodes which can reach it
+type SynologyTopology struct {
+	// Key is the node label describing the location of a node. Defaults to topology.kubernetes.io/zone.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Values are the values of the node label of the nodes which can reach the NAS, e.g. zones
+	Values []string `json:"values"`
+}
+
+// SynologyNAS is a further NAS the volumes of the shoots can be provisioned on, it gets a StorageClass of its own
+type SynologyNAS struct {
+	// Name identifies the NAS, its StorageClass is named synology-iscsi-<name>
+	Name string `json:"name"`
+	// URL is the URL of the DSM API of the NAS
+	URL string `json:"url"`
+	// SecretRef is the name of the shoot resource referencing the admin credentials of the NAS
+	SecretRef string `json:"secretRef"`
+	// Location is the DSM volume the LUNs are created on. Defaults to /volume1.
+	// +optional
+	Location string `json:"location,omitempty"`
+	// Topology restricts volumes of the NAS to the nodes which can reach it
+	// +optional
+	Topology *SynologyTopology `json:"topology,omitempty"`
+}
+
+// SynologyRateLimit limits the requests of the extension to the DSM API of the NAS across all shoots
+type SynologyRateLimit struct {
+	// RequestsPerSecond is the sustained rate of requests. Defaults to 5.
+	// +optional
+	RequestsPerSecond *int32 `json:"requestsPerSecond,omitempty"`
+	// Burst is the number of requests which may exceed the rate at once. Defaults to 10.
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+	// MaxConcurrent is the maximum number of requests in flight. Defaults to 4.
+	// +optional
+	MaxConcurrent *int32 `json:"maxConcurrent,omitempty"`
+}
+
+// SynologyRetry configures the retries of requests to the DSM API which failed because of transport errors,
+// timeouts or 5xx responses
+type SynologyRetry struct {
+	// MaxRetries is the number of retries of a request, 0 disables retries. Defaults to 3.
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// InitialBackoff is the wait before the first retry, it doubles with each further retry. Defaults to 500ms.
+	// +optional
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+	// MaxBackoff caps the wait between retries. Defaults to 10s.
+	// +optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// SynologySnapshotClass configures the VolumeSnapshotClass deployed into the shoot
+type SynologySnapshotClass struct {
+	// DeletionPolicy is the deletion policy of the VolumeSnapshotClass, either Delete or Retain. Defaults to Delete.
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Parameters are the DSM snapshot parameters, e.g. description and is_locked
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
@@ -14,6 +14,8 @@ import (
 
 	configv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
 	config "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -45,6 +47,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ImageOverwrite)(nil), (*config.ImageOverwrite)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ImageOverwrite_To_config_ImageOverwrite(a.(*ImageOverwrite), b.(*config.ImageOverwrite), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.ImageOverwrite)(nil), (*ImageOverwrite)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_ImageOverwrite_To_v1alpha1_ImageOverwrite(a.(*config.ImageOverwrite), b.(*ImageOverwrite), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*SynologyConfiguration)(nil), (*config.SynologyConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_SynologyConfiguration_To_config_SynologyConfiguration(a.(*SynologyConfiguration), b.(*config.SynologyConfiguration), scope)
 	}); err != nil {
@@ -55,6 +67,46 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*SynologyNAS)(nil), (*config.SynologyNAS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SynologyNAS_To_config_SynologyNAS(a.(*SynologyNAS), b.(*config.SynologyNAS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.SynologyNAS)(nil), (*SynologyNAS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_SynologyNAS_To_v1alpha1_SynologyNAS(a.(*config.SynologyNAS), b.(*SynologyNAS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SynologyRateLimit)(nil), (*config.SynologyRateLimit)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SynologyRateLimit_To_config_SynologyRateLimit(a.(*SynologyRateLimit), b.(*config.SynologyRateLimit), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.SynologyRateLimit)(nil), (*SynologyRateLimit)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_SynologyRateLimit_To_v1alpha1_SynologyRateLimit(a.(*config.SynologyRateLimit), b.(*SynologyRateLimit), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SynologyRetry)(nil), (*config.SynologyRetry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SynologyRetry_To_config_SynologyRetry(a.(*SynologyRetry), b.(*config.SynologyRetry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.SynologyRetry)(nil), (*SynologyRetry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_SynologyRetry_To_v1alpha1_SynologyRetry(a.(*config.SynologyRetry), b.(*SynologyRetry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SynologySnapshotClass)(nil), (*config.SynologySnapshotClass)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass(a.(*SynologySnapshotClass), b.(*config.SynologySnapshotClass), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.SynologySnapshotClass)(nil), (*SynologySnapshotClass)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass(a.(*config.SynologySnapshotClass), b.(*SynologySnapshotClass), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*SynologyStorageClasses)(nil), (*config.SynologyStorageClasses)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_SynologyStorageClasses_To_config_SynologyStorageClasses(a.(*SynologyStorageClasses), b.(*config.SynologyStorageClasses), scope)
 	}); err != nil {
@@ -65,6 +117,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*SynologyTopology)(nil), (*config.SynologyTopology)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SynologyTopology_To_config_SynologyTopology(a.(*SynologyTopology), b.(*config.SynologyTopology), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.SynologyTopology)(nil), (*SynologyTopology)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_SynologyTopology_To_v1alpha1_SynologyTopology(a.(*config.SynologyTopology), b.(*SynologyTopology), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*WorkloadResources)(nil), (*config.WorkloadResources)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_WorkloadResources_To_config_WorkloadResources(a.(*WorkloadResources), b.(*config.WorkloadResources), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.WorkloadResources)(nil), (*WorkloadResources)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_WorkloadResources_To_v1alpha1_WorkloadResources(a.(*config.WorkloadResources), b.(*WorkloadResources), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -72,6 +144,13 @@ func autoConvert_v1alpha1_ControllerConfiguration_To_config_ControllerConfigurat
 	if err := Convert_v1alpha1_SynologyConfiguration_To_config_SynologyConfiguration(&in.SynologyConfig, &out.SynologyConfig, s); err != nil {
 		return err
 	}
+	out.ControllerInSeed = in.ControllerInSeed
+	out.ControllerReplicas = (*int32)(unsafe.Pointer(in.ControllerReplicas))
+	out.Images = *(*[]config.ImageOverwrite)(unsafe.Pointer(&in.Images))
+	out.Resources = (*config.WorkloadResources)(unsafe.Pointer(in.Resources))
+	out.AdditionalEgressCIDRs = *(*[]string)(unsafe.Pointer(&in.AdditionalEgressCIDRs))
+	out.CapacityRefreshInterval = (*v1.Duration)(unsafe.Pointer(in.CapacityRefreshInterval))
+	out.DisableUserWhileHibernated = in.DisableUserWhileHibernated
 	out.HealthCheckConfig = (*configv1alpha1.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
 	return nil
 }
@@ -85,6 +164,13 @@ func autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfigurat
 	if err := Convert_config_SynologyConfiguration_To_v1alpha1_SynologyConfiguration(&in.SynologyConfig, &out.SynologyConfig, s); err != nil {
 		return err
 	}
+	out.ControllerInSeed = in.ControllerInSeed
+	out.ControllerReplicas = (*int32)(unsafe.Pointer(in.ControllerReplicas))
+	out.Images = *(*[]ImageOverwrite)(unsafe.Pointer(&in.Images))
+	out.Resources = (*WorkloadResources)(unsafe.Pointer(in.Resources))
+	out.AdditionalEgressCIDRs = *(*[]string)(unsafe.Pointer(&in.AdditionalEgressCIDRs))
+	out.CapacityRefreshInterval = (*v1.Duration)(unsafe.Pointer(in.CapacityRefreshInterval))
+	out.DisableUserWhileHibernated = in.DisableUserWhileHibernated
 	out.HealthCheckConfig = (*configv1alpha1.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
 	return nil
 }
@@ -114,12 +200,43 @@ func Convert_config_ISCSIStorageClass_To_v1alpha1_ISCSIStorageClass(in *config.I
 	return autoConvert_config_ISCSIStorageClass_To_v1alpha1_ISCSIStorageClass(in, out, s)
 }
 
+func autoConvert_v1alpha1_ImageOverwrite_To_config_ImageOverwrite(in *ImageOverwrite, out *config.ImageOverwrite, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Repository = (*string)(unsafe.Pointer(in.Repository))
+	out.Tag = (*string)(unsafe.Pointer(in.Tag))
+	return nil
+}
+
+// Convert_v1alpha1_ImageOverwrite_To_config_ImageOverwrite is an autogenerated conversion function.
+func Convert_v1alpha1_ImageOverwrite_To_config_ImageOverwrite(in *ImageOverwrite, out *config.ImageOverwrite, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ImageOverwrite_To_config_ImageOverwrite(in, out, s)
+}
+
+func autoConvert_config_ImageOverwrite_To_v1alpha1_ImageOverwrite(in *config.ImageOverwrite, out *ImageOverwrite, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Repository = (*string)(unsafe.Pointer(in.Repository))
+	out.Tag = (*string)(unsafe.Pointer(in.Tag))
+	return nil
+}
+
+// Convert_config_ImageOverwrite_To_v1alpha1_ImageOverwrite is an autogenerated conversion function.
+func Convert_config_ImageOverwrite_To_v1alpha1_ImageOverwrite(in *config.ImageOverwrite, out *ImageOverwrite, s conversion.Scope) error {
+	return autoConvert_config_ImageOverwrite_To_v1alpha1_ImageOverwrite(in, out, s)
+}
+
 func autoConvert_v1alpha1_SynologyConfiguration_To_config_SynologyConfiguration(in *SynologyConfiguration, out *config.SynologyConfiguration, s conversion.Scope) error {
 	out.URL = in.URL
 	out.SecretRef = in.SecretRef
 	if err := Convert_v1alpha1_SynologyStorageClasses_To_config_SynologyStorageClasses(&in.StorageClasses, &out.StorageClasses, s); err != nil {
 		return err
 	}
+	if err := Convert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass(&in.SnapshotClass, &out.SnapshotClass, s); err != nil {
+		return err
+	}
+	out.Topology = (*config.SynologyTopology)(unsafe.Pointer(in.Topology))
+	out.AdditionalNAS = *(*[]config.SynologyNAS)(unsafe.Pointer(&in.AdditionalNAS))
+	out.RateLimit = (*config.SynologyRateLimit)(unsafe.Pointer(in.RateLimit))
+	out.Retry = (*config.SynologyRetry)(unsafe.Pointer(in.Retry))
 	return nil
 }
 
@@ -134,6 +251,13 @@ func autoConvert_config_SynologyConfiguration_To_v1alpha1_SynologyConfiguration(
 	if err := Convert_config_SynologyStorageClasses_To_v1alpha1_SynologyStorageClasses(&in.StorageClasses, &out.StorageClasses, s); err != nil {
 		return err
 	}
+	if err := Convert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass(&in.SnapshotClass, &out.SnapshotClass, s); err != nil {
+		return err
+	}
+	out.Topology = (*SynologyTopology)(unsafe.Pointer(in.Topology))
+	out.AdditionalNAS = *(*[]SynologyNAS)(unsafe.Pointer(&in.AdditionalNAS))
+	out.RateLimit = (*SynologyRateLimit)(unsafe.Pointer(in.RateLimit))
+	out.Retry = (*SynologyRetry)(unsafe.Pointer(in.Retry))
 	return nil
 }
 
@@ -142,6 +266,104 @@ func Convert_config_SynologyConfiguration_To_v1alpha1_SynologyConfiguration(in *
 	return autoConvert_config_SynologyConfiguration_To_v1alpha1_SynologyConfiguration(in, out, s)
 }
 
+func autoConvert_v1alpha1_SynologyNAS_To_config_SynologyNAS(in *SynologyNAS, out *config.SynologyNAS, s conversion.Scope) error {
+	out.Name = in.Name
+	out.URL = in.URL
+	out.SecretRef = in.SecretRef
+	out.Location = in.Location
+	out.Topology = (*config.SynologyTopology)(unsafe.Pointer(in.Topology))
+	return nil
+}
+
+// Convert_v1alpha1_SynologyNAS_To_config_SynologyNAS is an autogenerated conversion function.
+func Convert_v1alpha1_SynologyNAS_To_config_SynologyNAS(in *SynologyNAS, out *config.SynologyNAS, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SynologyNAS_To_config_SynologyNAS(in, out, s)
+}
+
+func autoConvert_config_SynologyNAS_To_v1alpha1_SynologyNAS(in *config.SynologyNAS, out *SynologyNAS, s conversion.Scope) error {
+	out.Name = in.Name
+	out.URL = in.URL
+	out.SecretRef = in.SecretRef
+	out.Location = in.Location
+	out.Topology = (*SynologyTopology)(unsafe.Pointer(in.Topology))
+	return nil
+}
+
+// Convert_config_SynologyNAS_To_v1alpha1_SynologyNAS is an autogenerated conversion function.
+func Convert_config_SynologyNAS_To_v1alpha1_SynologyNAS(in *config.SynologyNAS, out *SynologyNAS, s conversion.Scope) error {
+	return autoConvert_config_SynologyNAS_To_v1alpha1_SynologyNAS(in, out, s)
+}
+
+func autoConvert_v1alpha1_SynologyRateLimit_To_config_SynologyRateLimit(in *SynologyRateLimit, out *config.SynologyRateLimit, s conversion.Scope) error {
+	out.RequestsPerSecond = (*int32)(unsafe.Pointer(in.RequestsPerSecond))
+	out.Burst = (*int32)(unsafe.Pointer(in.Burst))
+	out.MaxConcurrent = (*int32)(unsafe.Pointer(in.MaxConcurrent))
+	return nil
+}
+
+// Convert_v1alpha1_SynologyRateLimit_To_config_SynologyRateLimit is an autogenerated conversion function.
+func Convert_v1alpha1_SynologyRateLimit_To_config_SynologyRateLimit(in *SynologyRateLimit, out *config.SynologyRateLimit, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SynologyRateLimit_To_config_SynologyRateLimit(in, out, s)
+}
+
+func autoConvert_config_SynologyRateLimit_To_v1alpha1_SynologyRateLimit(in *config.SynologyRateLimit, out *SynologyRateLimit, s conversion.Scope) error {
+	out.RequestsPerSecond = (*int32)(unsafe.Pointer(in.RequestsPerSecond))
+	out.Burst = (*int32)(unsafe.Pointer(in.Burst))
+	out.MaxConcurrent = (*int32)(unsafe.Pointer(in.MaxConcurrent))
+	return nil
+}
+
+// Convert_config_SynologyRateLimit_To_v1alpha1_SynologyRateLimit is an autogenerated conversion function.
+func Convert_config_SynologyRateLimit_To_v1alpha1_SynologyRateLimit(in *config.SynologyRateLimit, out *SynologyRateLimit, s conversion.Scope) error {
+	return autoConvert_config_SynologyRateLimit_To_v1alpha1_SynologyRateLimit(in, out, s)
+}
+
+func autoConvert_v1alpha1_SynologyRetry_To_config_SynologyRetry(in *SynologyRetry, out *config.SynologyRetry, s conversion.Scope) error {
+	out.MaxRetries = (*int32)(unsafe.Pointer(in.MaxRetries))
+	out.InitialBackoff = (*v1.Duration)(unsafe.Pointer(in.InitialBackoff))
+	out.MaxBackoff = (*v1.Duration)(unsafe.Pointer(in.MaxBackoff))
+	return nil
+}
+
+// Convert_v1alpha1_SynologyRetry_To_config_SynologyRetry is an autogenerated conversion function.
+func Convert_v1alpha1_SynologyRetry_To_config_SynologyRetry(in *SynologyRetry, out *config.SynologyRetry, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SynologyRetry_To_config_SynologyRetry(in, out, s)
+}
+
+func autoConvert_config_SynologyRetry_To_v1alpha1_SynologyRetry(in *config.SynologyRetry, out *SynologyRetry, s conversion.Scope) error {
+	out.MaxRetries = (*int32)(unsafe.Pointer(in.MaxRetries))
+	out.InitialBackoff = (*v1.Duration)(unsafe.Pointer(in.InitialBackoff))
+	out.MaxBackoff = (*v1.Duration)(unsafe.Pointer(in.MaxBackoff))
+	return nil
+}
+
+// Convert_config_SynologyRetry_To_v1alpha1_SynologyRetry is an autogenerated conversion function.
+func Convert_config_SynologyRetry_To_v1alpha1_SynologyRetry(in *config.SynologyRetry, out *SynologyRetry, s conversion.Scope) error {
+	return autoConvert_config_SynologyRetry_To_v1alpha1_SynologyRetry(in, out, s)
+}
+
+func autoConvert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass(in *SynologySnapshotClass, out *config.SynologySnapshotClass, s conversion.Scope) error {
+	out.DeletionPolicy = in.DeletionPolicy
+	out.Parameters = *(*map[string]string)(unsafe.Pointer(&in.Parameters))
+	return nil
+}
+
+// Convert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass is an autogenerated conversion function.
+func Convert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass(in *SynologySnapshotClass, out *config.SynologySnapshotClass, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SynologySnapshotClass_To_config_SynologySnapshotClass(in, out, s)
+}
+
+func autoConvert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass(in *config.SynologySnapshotClass, out *SynologySnapshotClass, s conversion.Scope) error {
+	out.DeletionPolicy = in.DeletionPolicy
+	out.Parameters = *(*map[string]string)(unsafe.Pointer(&in.Parameters))
+	return nil
+}
+
+// Convert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass is an autogenerated conversion function.
+func Convert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass(in *config.SynologySnapshotClass, out *SynologySnapshotClass, s conversion.Scope) error {
+	return autoConvert_config_SynologySnapshotClass_To_v1alpha1_SynologySnapshotClass(in, out, s)
+}
+
 func autoConvert_v1alpha1_SynologyStorageClasses_To_config_SynologyStorageClasses(in *SynologyStorageClasses, out *config.SynologyStorageClasses, s conversion.Scope) error {
 	if err := Convert_v1alpha1_ISCSIStorageClass_To_config_ISCSIStorageClass(&in.ISCSI, &out.ISCSI, s); err != nil {
 		return err
@@ -165,3 +387,47 @@ func autoConvert_config_SynologyStorageClasses_To_v1alpha1_SynologyStorageClasse
 func Convert_config_SynologyStorageClasses_To_v1alpha1_SynologyStorageClasses(in *config.SynologyStorageClasses, out *SynologyStorageClasses, s conversion.Scope) error {
 	return autoConvert_config_SynologyStorageClasses_To_v1alpha1_SynologyStorageClasses(in, out, s)
 }
+
+func autoConvert_v1alpha1_SynologyTopology_To_config_SynologyTopology(in *SynologyTopology, out *config.SynologyTopology, s conversion.Scope) error {
+	out.Key = in.Key
+	out.Values = *(*[]string)(unsafe.Pointer(&in.Values))
+	return nil
+}
+
+// Convert_v1alpha1_SynologyTopology_To_config_SynologyTopology is an autogenerated conversion function.
+func Convert_v1alpha1_SynologyTopology_To_config_SynologyTopology(in *SynologyTopology, out *config.SynologyTopology, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SynologyTopology_To_config_SynologyTopology(in, out, s)
+}
+
+func autoConvert_config_SynologyTopology_To_v1alpha1_SynologyTopology(in *config.SynologyTopology, out *SynologyTopology, s conversion.Scope) error {
+	out.Key = in.Key
+	out.Values = *(*[]string)(unsafe.Pointer(&in.Values))
+	return nil
+}
+
+// Convert_config_SynologyTopology_To_v1alpha1_SynologyTopology is an autogenerated conversion function.
+func Convert_config_SynologyTopology_To_v1alpha1_SynologyTopology(in *config.SynologyTopology, out *SynologyTopology, s conversion.Scope) error {
+	return autoConvert_config_SynologyTopology_To_v1alpha1_SynologyTopology(in, out, s)
+}
+
+func autoConvert_v1alpha1_WorkloadResources_To_config_WorkloadResources(in *WorkloadResources, out *config.WorkloadResources, s conversion.Scope) error {
+	out.Controller = *(*map[string]corev1.ResourceRequirements)(unsafe.Pointer(&in.Controller))
+	out.Node = *(*map[string]corev1.ResourceRequirements)(unsafe.Pointer(&in.Node))
+	return nil
+}
+
+// Convert_v1alpha1_WorkloadResources_To_config_WorkloadResources is an autogenerated conversion function.
+func Convert_v1alpha1_WorkloadResources_To_config_WorkloadResources(in *WorkloadResources, out *config.WorkloadResources, s conversion.Scope) error {
+	return autoConvert_v1alpha1_WorkloadResources_To_config_WorkloadResources(in, out, s)
+}
+
+func autoConvert_config_WorkloadResources_To_v1alpha1_WorkloadResources(in *config.WorkloadResources, out *WorkloadResources, s conversion.Scope) error {
+	out.Controller = *(*map[string]corev1.ResourceRequirements)(unsafe.Pointer(&in.Controller))
+	out.Node = *(*map[string]corev1.ResourceRequirements)(unsafe.Pointer(&in.Node))
+	return nil
+}
+
+// Convert_config_WorkloadResources_To_v1alpha1_WorkloadResources is an autogenerated conversion function.
+func Convert_config_WorkloadResources_To_v1alpha1_WorkloadResources(in *config.WorkloadResources, out *WorkloadResources, s conversion.Scope) error {
+	return autoConvert_config_WorkloadResources_To_v1alpha1_WorkloadResources(in, out, s)
+}
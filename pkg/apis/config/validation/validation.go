@@ -1,12 +1,18 @@
 package validation
 
 import (
+	"net"
 	"net/url"
+	"slices"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	config "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/imagevector"
 )
 
 func ValidateConfiguration(cfg *config.ControllerConfiguration) field.ErrorList {
@@ -28,6 +34,109 @@ func ValidateConfiguration(cfg *config.ControllerConfiguration) field.ErrorList
 		allErrs = append(allErrs, field.Required(synPath.Child("secretRef"), "must be set"))
 	}
 
+	if cfg.ControllerReplicas != nil && *cfg.ControllerReplicas < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("controllerReplicas"), *cfg.ControllerReplicas, "must be at least 1"))
+	}
+
+	for i, image := range cfg.Images {
+		imagePath := fldPath.Child("images").Index(i)
+		if !slices.Contains(constants.ImageNames, image.Name) {
+			allErrs = append(allErrs, field.NotSupported(imagePath.Child("name"), image.Name, constants.ImageNames))
+		}
+		if image.Repository == nil && image.Tag == nil {
+			allErrs = append(allErrs, field.Required(imagePath, "repository or tag must be set"))
+		} else if err := imagevector.ValidateOverwrite(imagevector.ImageVector(), image); err != nil {
+			allErrs = append(allErrs, field.Invalid(imagePath, image.Name, err.Error()))
+		}
+	}
+
+	if cfg.Resources != nil {
+		resourcesPath := fldPath.Child("resources")
+		for name := range cfg.Resources.Controller {
+			if !slices.Contains(constants.ControllerContainerNames, name) {
+				allErrs = append(allErrs, field.NotSupported(resourcesPath.Child("controller").Key(name), name, constants.ControllerContainerNames))
+			}
+		}
+		for name := range cfg.Resources.Node {
+			if !slices.Contains(constants.NodeContainerNames, name) {
+				allErrs = append(allErrs, field.NotSupported(resourcesPath.Child("node").Key(name), name, constants.NodeContainerNames))
+			}
+		}
+	}
+
+	if cfg.CapacityRefreshInterval != nil && cfg.CapacityRefreshInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("capacityRefreshInterval"), cfg.CapacityRefreshInterval.Duration.String(), "must be positive"))
+	}
+
+	for i, cidr := range cfg.AdditionalEgressCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("additionalEgressCIDRs").Index(i), cidr, "must be a valid CIDR"))
+		}
+	}
+
+	allErrs = append(allErrs, validateTopology(cfg.SynologyConfig.Topology, synPath.Child("topology"))...)
+
+	names := sets.New[string]()
+	for i, nas := range cfg.SynologyConfig.AdditionalNAS {
+		nasPath := synPath.Child("additionalNAS").Index(i)
+
+		for _, msg := range validation.IsDNS1123Label(nas.Name) {
+			allErrs = append(allErrs, field.Invalid(nasPath.Child("name"), nas.Name, msg))
+		}
+		if names.Has(nas.Name) {
+			allErrs = append(allErrs, field.Duplicate(nasPath.Child("name"), nas.Name))
+		}
+		names.Insert(nas.Name)
+
+		if nas.URL == "" {
+			allErrs = append(allErrs, field.Required(nasPath.Child("url"), "must be set"))
+		} else if _, err := url.ParseRequestURI(nas.URL); err != nil {
+			allErrs = append(allErrs, field.Invalid(nasPath.Child("url"), nas.URL, "must be a valid URL"))
+		}
+		if strings.TrimSpace(nas.SecretRef) == "" {
+			allErrs = append(allErrs, field.Required(nasPath.Child("secretRef"), "must be set"))
+		}
+		if nas.Location != "" && !strings.HasPrefix(nas.Location, "/") {
+			allErrs = append(allErrs, field.Invalid(nasPath.Child("location"), nas.Location, "must be an absolute path, e.g. /volume1"))
+		}
+
+		allErrs = append(allErrs, validateTopology(nas.Topology, nasPath.Child("topology"))...)
+	}
+
+	if rateLimit := cfg.SynologyConfig.RateLimit; rateLimit != nil {
+		rateLimitPath := synPath.Child("rateLimit")
+		if rateLimit.RequestsPerSecond != nil && *rateLimit.RequestsPerSecond < 1 {
+			allErrs = append(allErrs, field.Invalid(rateLimitPath.Child("requestsPerSecond"), *rateLimit.RequestsPerSecond, "must be at least 1"))
+		}
+		if rateLimit.Burst != nil && *rateLimit.Burst < 1 {
+			allErrs = append(allErrs, field.Invalid(rateLimitPath.Child("burst"), *rateLimit.Burst, "must be at least 1"))
+		}
+		if rateLimit.MaxConcurrent != nil && *rateLimit.MaxConcurrent < 1 {
+			allErrs = append(allErrs, field.Invalid(rateLimitPath.Child("maxConcurrent"), *rateLimit.MaxConcurrent, "must be at least 1"))
+		}
+	}
+
+	if retry := cfg.SynologyConfig.Retry; retry != nil {
+		retryPath := synPath.Child("retry")
+		if retry.MaxRetries != nil && *retry.MaxRetries < 0 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("maxRetries"), *retry.MaxRetries, "must not be negative"))
+		}
+		if retry.InitialBackoff != nil && retry.InitialBackoff.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("initialBackoff"), retry.InitialBackoff.Duration.String(), "must be positive"))
+		}
+		if retry.MaxBackoff != nil && retry.MaxBackoff.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("maxBackoff"), retry.MaxBackoff.Duration.String(), "must be positive"))
+		}
+		if retry.InitialBackoff != nil && retry.MaxBackoff != nil && retry.MaxBackoff.Duration < retry.InitialBackoff.Duration {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("maxBackoff"), retry.MaxBackoff.Duration.String(), "must not be less than initialBackoff"))
+		}
+	}
+
+	snapshotClassPath := synPath.Child("snapshotClass")
+	if policy := cfg.SynologyConfig.SnapshotClass.DeletionPolicy; policy != "" && !slices.Contains(constants.SnapshotClassDeletionPolicies, policy) {
+		allErrs = append(allErrs, field.NotSupported(snapshotClassPath.Child("deletionPolicy"), policy, constants.SnapshotClassDeletionPolicies))
+	}
+
 	// storageClasses.iscsi.parameters
 	scPath := synPath.Child("storageClasses").Child("iscsi").Child("parameters")
 	params := cfg.SynologyConfig.StorageClasses.ISCSI.Parameters
@@ -38,3 +147,22 @@ func ValidateConfiguration(cfg *config.ControllerConfiguration) field.ErrorList
 
 	return allErrs
 }
+
+// validateTopology validates the topology of a NAS
+func validateTopology(topology *config.SynologyTopology, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if topology == nil {
+		return allErrs
+	}
+
+	if topology.Key != "" {
+		for _, msg := range validation.IsQualifiedName(topology.Key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("key"), topology.Key, msg))
+		}
+	}
+	if len(topology.Values) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("values"), "must contain at least one value"))
+	}
+
+	return allErrs
+}
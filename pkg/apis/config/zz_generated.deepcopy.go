@@ -11,6 +11,8 @@ package config
 
 import (
 	v1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -19,6 +21,33 @@ func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.SynologyConfig.DeepCopyInto(&out.SynologyConfig)
+	if in.ControllerReplicas != nil {
+		in, out := &in.ControllerReplicas, &out.ControllerReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageOverwrite, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(WorkloadResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalEgressCIDRs != nil {
+		in, out := &in.AdditionalEgressCIDRs, &out.AdditionalEgressCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CapacityRefreshInterval != nil {
+		in, out := &in.CapacityRefreshInterval, &out.CapacityRefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.HealthCheckConfig != nil {
 		in, out := &in.HealthCheckConfig, &out.HealthCheckConfig
 		*out = new(v1alpha1.HealthCheckConfig)
@@ -68,10 +97,59 @@ func (in *ISCSIStorageClass) DeepCopy() *ISCSIStorageClass {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageOverwrite) DeepCopyInto(out *ImageOverwrite) {
+	*out = *in
+	if in.Repository != nil {
+		in, out := &in.Repository, &out.Repository
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tag != nil {
+		in, out := &in.Tag, &out.Tag
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageOverwrite.
+func (in *ImageOverwrite) DeepCopy() *ImageOverwrite {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageOverwrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SynologyConfiguration) DeepCopyInto(out *SynologyConfiguration) {
 	*out = *in
 	in.StorageClasses.DeepCopyInto(&out.StorageClasses)
+	in.SnapshotClass.DeepCopyInto(&out.SnapshotClass)
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(SynologyTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalNAS != nil {
+		in, out := &in.AdditionalNAS, &out.AdditionalNAS
+		*out = make([]SynologyNAS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(SynologyRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(SynologyRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -85,6 +163,112 @@ func (in *SynologyConfiguration) DeepCopy() *SynologyConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SynologyNAS) DeepCopyInto(out *SynologyNAS) {
+	*out = *in
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(SynologyTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SynologyNAS.
+func (in *SynologyNAS) DeepCopy() *SynologyNAS {
+	if in == nil {
+		return nil
+	}
+	out := new(SynologyNAS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SynologyRateLimit) DeepCopyInto(out *SynologyRateLimit) {
+	*out = *in
+	if in.RequestsPerSecond != nil {
+		in, out := &in.RequestsPerSecond, &out.RequestsPerSecond
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrent != nil {
+		in, out := &in.MaxConcurrent, &out.MaxConcurrent
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SynologyRateLimit.
+func (in *SynologyRateLimit) DeepCopy() *SynologyRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(SynologyRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SynologyRetry) DeepCopyInto(out *SynologyRetry) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SynologyRetry.
+func (in *SynologyRetry) DeepCopy() *SynologyRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(SynologyRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SynologySnapshotClass) DeepCopyInto(out *SynologySnapshotClass) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SynologySnapshotClass.
+func (in *SynologySnapshotClass) DeepCopy() *SynologySnapshotClass {
+	if in == nil {
+		return nil
+	}
+	out := new(SynologySnapshotClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SynologyStorageClasses) DeepCopyInto(out *SynologyStorageClasses) {
 	*out = *in
@@ -101,3 +285,54 @@ func (in *SynologyStorageClasses) DeepCopy() *SynologyStorageClasses {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SynologyTopology) DeepCopyInto(out *SynologyTopology) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SynologyTopology.
+func (in *SynologyTopology) DeepCopy() *SynologyTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(SynologyTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadResources) DeepCopyInto(out *WorkloadResources) {
+	*out = *in
+	if in.Controller != nil {
+		in, out := &in.Controller, &out.Controller
+		*out = make(map[string]corev1.ResourceRequirements, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Node != nil {
+		in, out := &in.Node, &out.Node
+		*out = make(map[string]corev1.ResourceRequirements, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadResources.
+func (in *WorkloadResources) DeepCopy() *WorkloadResources {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadResources)
+	in.DeepCopyInto(out)
+	return out
+}
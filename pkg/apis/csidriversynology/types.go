@@ -2,6 +2,7 @@ package csidriversynology
 
 import (
 	apisconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,4 +24,69 @@ type CsiDriverSynologyConfig struct {
 	// HealthCheckConfig is the config for the health check controller
 	// +optional
 	HealthCheckConfig *apisconfigv1alpha1.HealthCheckConfig
+
+	// NodePlugin configures the placement of the CSI node plugin
+	// +optional
+	NodePlugin *NodePluginConfig
+
+	// Logging configures the log output of the CSI driver and its sidecars
+	// +optional
+	Logging *LoggingConfig
+
+	// Suspended freezes the shoot's access to the NAS. The DSM user of the shoot is disabled, its LUNs are unmapped
+	// from their iSCSI targets and the CSI controller is scaled to zero until the flag is unset.
+	// +optional
+	Suspended bool
+}
+
+// NodePluginConfig configures the placement of the CSI node plugin
+type NodePluginConfig struct {
+	// WorkerPools are the names of the worker pools the node plugin runs on, defaults to all worker pools
+	// +optional
+	WorkerPools []string
+
+	// Tolerations are added to the default tolerations of the node plugin
+	// +optional
+	Tolerations []corev1.Toleration
+}
+
+// LoggingConfig configures the log output of the CSI driver and its sidecars
+type LoggingConfig struct {
+	// LogLevel is the log level of the Synology CSI driver, one of debug, info, warn or error. Defaults to info.
+	// +optional
+	LogLevel *string
+
+	// Verbosity is the klog verbosity of the CSI sidecars. Defaults to 2.
+	// +optional
+	Verbosity *int32
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CsiDriverSynologyStatus is the provider status of the extension, describing what was provisioned on the NAS and
+// deployed into the shoot
+type CsiDriverSynologyStatus struct {
+	metav1.TypeMeta
+
+	// Username is the DSM user created for the shoot
+	Username string
+
+	// Endpoint is the URL of the DSM API of the NAS
+	Endpoint string
+
+	// DSMVersion is the version of the DiskStation Manager of the NAS
+	// +optional
+	DSMVersion string
+
+	// StorageClasses are the names of the storage classes deployed into the shoot
+	// +optional
+	StorageClasses []string
+
+	// LastCredentialRotation is the time the password of the DSM user was last set
+	// +optional
+	LastCredentialRotation *metav1.Time
+
+	// DebugUntil is the end of the debug mode of the CSI driver, the log output is reverted then
+	// +optional
+	DebugUntil *metav1.Time
 }
@@ -33,6 +33,7 @@ func init() {
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&CsiDriverSynologyConfig{},
+		&CsiDriverSynologyStatus{},
 	)
 	return nil
 }
@@ -14,6 +14,8 @@ import (
 
 	configv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
 	csidriversynology "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -35,6 +37,36 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*CsiDriverSynologyStatus)(nil), (*csidriversynology.CsiDriverSynologyStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CsiDriverSynologyStatus_To_csidriversynology_CsiDriverSynologyStatus(a.(*CsiDriverSynologyStatus), b.(*csidriversynology.CsiDriverSynologyStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*csidriversynology.CsiDriverSynologyStatus)(nil), (*CsiDriverSynologyStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_csidriversynology_CsiDriverSynologyStatus_To_v1alpha1_CsiDriverSynologyStatus(a.(*csidriversynology.CsiDriverSynologyStatus), b.(*CsiDriverSynologyStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*LoggingConfig)(nil), (*csidriversynology.LoggingConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_LoggingConfig_To_csidriversynology_LoggingConfig(a.(*LoggingConfig), b.(*csidriversynology.LoggingConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*csidriversynology.LoggingConfig)(nil), (*LoggingConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_csidriversynology_LoggingConfig_To_v1alpha1_LoggingConfig(a.(*csidriversynology.LoggingConfig), b.(*LoggingConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NodePluginConfig)(nil), (*csidriversynology.NodePluginConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_NodePluginConfig_To_csidriversynology_NodePluginConfig(a.(*NodePluginConfig), b.(*csidriversynology.NodePluginConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*csidriversynology.NodePluginConfig)(nil), (*NodePluginConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_csidriversynology_NodePluginConfig_To_v1alpha1_NodePluginConfig(a.(*csidriversynology.NodePluginConfig), b.(*NodePluginConfig), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -43,6 +75,9 @@ func autoConvert_v1alpha1_CsiDriverSynologyConfig_To_csidriversynology_CsiDriver
 	out.Username = in.Username
 	out.Password = in.Password
 	out.HealthCheckConfig = (*configv1alpha1.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
+	out.NodePlugin = (*csidriversynology.NodePluginConfig)(unsafe.Pointer(in.NodePlugin))
+	out.Logging = (*csidriversynology.LoggingConfig)(unsafe.Pointer(in.Logging))
+	out.Suspended = in.Suspended
 	return nil
 }
 
@@ -56,6 +91,9 @@ func autoConvert_csidriversynology_CsiDriverSynologyConfig_To_v1alpha1_CsiDriver
 	out.Username = in.Username
 	out.Password = in.Password
 	out.HealthCheckConfig = (*configv1alpha1.HealthCheckConfig)(unsafe.Pointer(in.HealthCheckConfig))
+	out.NodePlugin = (*NodePluginConfig)(unsafe.Pointer(in.NodePlugin))
+	out.Logging = (*LoggingConfig)(unsafe.Pointer(in.Logging))
+	out.Suspended = in.Suspended
 	return nil
 }
 
@@ -63,3 +101,77 @@ func autoConvert_csidriversynology_CsiDriverSynologyConfig_To_v1alpha1_CsiDriver
 func Convert_csidriversynology_CsiDriverSynologyConfig_To_v1alpha1_CsiDriverSynologyConfig(in *csidriversynology.CsiDriverSynologyConfig, out *CsiDriverSynologyConfig, s conversion.Scope) error {
 	return autoConvert_csidriversynology_CsiDriverSynologyConfig_To_v1alpha1_CsiDriverSynologyConfig(in, out, s)
 }
+
+func autoConvert_v1alpha1_CsiDriverSynologyStatus_To_csidriversynology_CsiDriverSynologyStatus(in *CsiDriverSynologyStatus, out *csidriversynology.CsiDriverSynologyStatus, s conversion.Scope) error {
+	out.Username = in.Username
+	out.Endpoint = in.Endpoint
+	out.DSMVersion = in.DSMVersion
+	out.StorageClasses = *(*[]string)(unsafe.Pointer(&in.StorageClasses))
+	out.LastCredentialRotation = (*v1.Time)(unsafe.Pointer(in.LastCredentialRotation))
+	out.DebugUntil = (*v1.Time)(unsafe.Pointer(in.DebugUntil))
+	return nil
+}
+
+// Convert_v1alpha1_CsiDriverSynologyStatus_To_csidriversynology_CsiDriverSynologyStatus is an autogenerated conversion function.
+func Convert_v1alpha1_CsiDriverSynologyStatus_To_csidriversynology_CsiDriverSynologyStatus(in *CsiDriverSynologyStatus, out *csidriversynology.CsiDriverSynologyStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CsiDriverSynologyStatus_To_csidriversynology_CsiDriverSynologyStatus(in, out, s)
+}
+
+func autoConvert_csidriversynology_CsiDriverSynologyStatus_To_v1alpha1_CsiDriverSynologyStatus(in *csidriversynology.CsiDriverSynologyStatus, out *CsiDriverSynologyStatus, s conversion.Scope) error {
+	out.Username = in.Username
+	out.Endpoint = in.Endpoint
+	out.DSMVersion = in.DSMVersion
+	out.StorageClasses = *(*[]string)(unsafe.Pointer(&in.StorageClasses))
+	out.LastCredentialRotation = (*v1.Time)(unsafe.Pointer(in.LastCredentialRotation))
+	out.DebugUntil = (*v1.Time)(unsafe.Pointer(in.DebugUntil))
+	return nil
+}
+
+// Convert_csidriversynology_CsiDriverSynologyStatus_To_v1alpha1_CsiDriverSynologyStatus is an autogenerated conversion function.
+func Convert_csidriversynology_CsiDriverSynologyStatus_To_v1alpha1_CsiDriverSynologyStatus(in *csidriversynology.CsiDriverSynologyStatus, out *CsiDriverSynologyStatus, s conversion.Scope) error {
+	return autoConvert_csidriversynology_CsiDriverSynologyStatus_To_v1alpha1_CsiDriverSynologyStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_LoggingConfig_To_csidriversynology_LoggingConfig(in *LoggingConfig, out *csidriversynology.LoggingConfig, s conversion.Scope) error {
+	out.LogLevel = (*string)(unsafe.Pointer(in.LogLevel))
+	out.Verbosity = (*int32)(unsafe.Pointer(in.Verbosity))
+	return nil
+}
+
+// Convert_v1alpha1_LoggingConfig_To_csidriversynology_LoggingConfig is an autogenerated conversion function.
+func Convert_v1alpha1_LoggingConfig_To_csidriversynology_LoggingConfig(in *LoggingConfig, out *csidriversynology.LoggingConfig, s conversion.Scope) error {
+	return autoConvert_v1alpha1_LoggingConfig_To_csidriversynology_LoggingConfig(in, out, s)
+}
+
+func autoConvert_csidriversynology_LoggingConfig_To_v1alpha1_LoggingConfig(in *csidriversynology.LoggingConfig, out *LoggingConfig, s conversion.Scope) error {
+	out.LogLevel = (*string)(unsafe.Pointer(in.LogLevel))
+	out.Verbosity = (*int32)(unsafe.Pointer(in.Verbosity))
+	return nil
+}
+
+// Convert_csidriversynology_LoggingConfig_To_v1alpha1_LoggingConfig is an autogenerated conversion function.
+func Convert_csidriversynology_LoggingConfig_To_v1alpha1_LoggingConfig(in *csidriversynology.LoggingConfig, out *LoggingConfig, s conversion.Scope) error {
+	return autoConvert_csidriversynology_LoggingConfig_To_v1alpha1_LoggingConfig(in, out, s)
+}
+
+func autoConvert_v1alpha1_NodePluginConfig_To_csidriversynology_NodePluginConfig(in *NodePluginConfig, out *csidriversynology.NodePluginConfig, s conversion.Scope) error {
+	out.WorkerPools = *(*[]string)(unsafe.Pointer(&in.WorkerPools))
+	out.Tolerations = *(*[]corev1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	return nil
+}
+
+// Convert_v1alpha1_NodePluginConfig_To_csidriversynology_NodePluginConfig is an autogenerated conversion function.
+func Convert_v1alpha1_NodePluginConfig_To_csidriversynology_NodePluginConfig(in *NodePluginConfig, out *csidriversynology.NodePluginConfig, s conversion.Scope) error {
+	return autoConvert_v1alpha1_NodePluginConfig_To_csidriversynology_NodePluginConfig(in, out, s)
+}
+
+func autoConvert_csidriversynology_NodePluginConfig_To_v1alpha1_NodePluginConfig(in *csidriversynology.NodePluginConfig, out *NodePluginConfig, s conversion.Scope) error {
+	out.WorkerPools = *(*[]string)(unsafe.Pointer(&in.WorkerPools))
+	out.Tolerations = *(*[]corev1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	return nil
+}
+
+// Convert_csidriversynology_NodePluginConfig_To_v1alpha1_NodePluginConfig is an autogenerated conversion function.
+func Convert_csidriversynology_NodePluginConfig_To_v1alpha1_NodePluginConfig(in *csidriversynology.NodePluginConfig, out *NodePluginConfig, s conversion.Scope) error {
+	return autoConvert_csidriversynology_NodePluginConfig_To_v1alpha1_NodePluginConfig(in, out, s)
+}
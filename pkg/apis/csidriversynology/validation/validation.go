@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+)
+
+var supportedTaintEffects = []corev1.TaintEffect{
+	corev1.TaintEffectNoSchedule,
+	corev1.TaintEffectPreferNoSchedule,
+	corev1.TaintEffectNoExecute,
+}
+
+// ValidateCsiDriverSynologyConfig validates the provider config of a shoot. The worker pools of the node plugin are
+// not checked against the shoot, as pools can be removed from the shoot after the provider config was written.
+func ValidateCsiDriverSynologyConfig(cfg *csidriversynology.CsiDriverSynologyConfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if cfg.NodePlugin != nil {
+		allErrs = append(allErrs, validateNodePlugin(cfg.NodePlugin, field.NewPath("nodePlugin"))...)
+	}
+
+	if cfg.Logging != nil {
+		allErrs = append(allErrs, validateLogging(cfg.Logging, field.NewPath("logging"))...)
+	}
+
+	return allErrs
+}
+
+func validateNodePlugin(cfg *csidriversynology.NodePluginConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, pool := range cfg.WorkerPools {
+		if pool == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("workerPools").Index(i), "must not be empty"))
+		}
+	}
+
+	for i, toleration := range cfg.Tolerations {
+		tolerationPath := fldPath.Child("tolerations").Index(i)
+
+		switch toleration.Operator {
+		case corev1.TolerationOpExists:
+			if toleration.Value != "" {
+				allErrs = append(allErrs, field.Invalid(tolerationPath.Child("value"), toleration.Value, "must be empty when operator is Exists"))
+			}
+		case corev1.TolerationOpEqual, "":
+			if toleration.Key == "" {
+				allErrs = append(allErrs, field.Required(tolerationPath.Child("key"), "must be set when operator is Equal"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(tolerationPath.Child("operator"), toleration.Operator, []corev1.TolerationOperator{corev1.TolerationOpExists, corev1.TolerationOpEqual}))
+		}
+
+		if toleration.Effect != "" && !slices.Contains(supportedTaintEffects, toleration.Effect) {
+			allErrs = append(allErrs, field.NotSupported(tolerationPath.Child("effect"), toleration.Effect, supportedTaintEffects))
+		}
+	}
+
+	return allErrs
+}
+
+func validateLogging(cfg *csidriversynology.LoggingConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if cfg.LogLevel != nil && !slices.Contains(constants.DriverLogLevels, *cfg.LogLevel) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("logLevel"), *cfg.LogLevel, constants.DriverLogLevels))
+	}
+
+	if cfg.Verbosity != nil && (*cfg.Verbosity < 0 || *cfg.Verbosity > 10) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("verbosity"), *cfg.Verbosity, "must be between 0 and 10"))
+	}
+
+	return allErrs
+}
@@ -3,9 +3,11 @@ package cmd
 import (
 	controllercmd "github.com/gardener/gardener/extensions/pkg/controller/cmd"
 	extensionsheartbeatcontroller "github.com/gardener/gardener/extensions/pkg/controller/heartbeat"
+	webhookcmd "github.com/gardener/gardener/extensions/pkg/webhook/cmd"
 
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
 	csidriversynology "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/controller/lifecycle"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/webhook/operatingsystemconfig"
 )
 
 // ControllerSwitchOptions are the controllercmd.SwitchOptions for the provider controllers.
@@ -15,3 +17,10 @@ func ControllerSwitchOptions() *controllercmd.SwitchOptions {
 		controllercmd.Switch(extensionsheartbeatcontroller.ControllerName, extensionsheartbeatcontroller.AddToManager),
 	)
 }
+
+// WebhookSwitchOptions are the webhookcmd.SwitchOptions for the extension webhooks.
+func WebhookSwitchOptions() *webhookcmd.SwitchOptions {
+	return webhookcmd.NewSwitchOptions(
+		webhookcmd.Switch(operatingsystemconfig.WebhookName, operatingsystemconfig.AddToManager),
+	)
+}
@@ -19,6 +19,15 @@ const (
 	// SecretName is the name of the secret containing Synology credentials
 	SecretName = "synology-csi-credentials"
 
+	// SeedCredentialsSecretName is the name of the secret in the shoot's control plane namespace keeping the
+	// credentials of the DSM user of the shoot, it is carried over into the ShootState during control plane migration
+	SeedCredentialsSecretName = "synology-csi-shoot-credentials"
+
+	// UserCleanupSecretName is the name of the secret in the namespace of the extension queueing the DSM users of
+	// force-deleted shoots for deletion, the secrets with the credentials of the DSM admins deleting them are prefixed
+	// with it
+	UserCleanupSecretName = "synology-csi-user-cleanup"
+
 	// ClientInfoSecretName is the name of the secret containing client info
 	ClientInfoSecretName = "synology-csi-client-info"
 
@@ -34,6 +43,13 @@ const (
 	// NodeName is the name of the CSI node
 	NodeName = "synology-csi-node"
 
+	// SeedManagedResourceName is the name of the managed resource holding the CSI controller when it runs in the seed
+	SeedManagedResourceName = ExtensionType + "-seed"
+
+	// CapacityManagedResourceName is the name of the managed resource holding the CSIStorageCapacity objects and the
+	// capacity ConfigMap in the shoot
+	CapacityManagedResourceName = ExtensionType + "-capacity"
+
 	// ProvisionerName is the name of the provisioner
 	ProvisionerName = CSIDriverName
 
@@ -46,26 +62,100 @@ const (
 	// SnapshotterName is the name of the snapshotter
 	SnapshotterName = "synology-csi-snapshotter"
 
-	// ImageCSIDriver is the image for the Synology CSI driver
-	ImageCSIDriver = "synology/synology-csi:v1.1.2"
+	// ISCSIPort is the port of the iSCSI target service of the DSM
+	ISCSIPort = 3260
+
+	// StorageClassName is the name of the default StorageClass, the StorageClasses of further NAS are suffixed with
+	// the name of the NAS
+	StorageClassName = "synology-iscsi"
+
+	// SnapshotClassName is the name of the VolumeSnapshotClass
+	SnapshotClassName = "synology-snapshotclass"
+
+	// DefaultVolumeLocation is the DSM volume the LUNs of the default StorageClass are created on
+	DefaultVolumeLocation = "/volume1"
+
+	// CapacityConfigMapName is the name of the ConfigMap in the shoot reporting the usage and status of the DSM volumes
+	CapacityConfigMapName = "synology-csi-capacity"
+
+	// ConditionTypeSnapshotAPIAvailable is the Extension condition reporting whether the shoot serves the volume snapshot API
+	ConditionTypeSnapshotAPIAvailable = "VolumeSnapshotAPIAvailable"
+
+	// ConditionTypeStorageCapacityPublished is the Extension condition reporting whether the capacity of every
+	// StorageClass of the CSI driver is published, the CSIDriver only enables storage capacity tracking then
+	ConditionTypeStorageCapacityPublished = "StorageCapacityPublished"
+
+	// EventReasonUserCreated is the reason of the Extension event emitted when the DSM user of the shoot was created
+	EventReasonUserCreated = "UserCreated"
+
+	// EventReasonCredentialsReused is the reason of the Extension event emitted when the existing DSM user of the shoot
+	// and its credentials are reused
+	EventReasonCredentialsReused = "CredentialsReused"
+
+	// EventReasonUserDeleted is the reason of the Extension event emitted when the DSM user of the shoot was deleted
+	EventReasonUserDeleted = "UserDeleted"
+
+	// EventReasonUserDisabled is the reason of the Extension event emitted when the DSM user of the shoot was disabled
+	EventReasonUserDisabled = "UserDisabled"
 
-	// ImageCSIProvisioner is the image for the CSI provisioner
-	ImageCSIProvisioner = "registry.k8s.io/sig-storage/csi-provisioner:v5.1.0"
+	// EventReasonUserEnabled is the reason of the Extension event emitted when the disabled DSM user of the shoot was
+	// enabled again
+	EventReasonUserEnabled = "UserEnabled"
 
-	// ImageCSIAttacher is the image for the CSI attacher
-	ImageCSIAttacher = "registry.k8s.io/sig-storage/csi-attacher:v4.7.0"
+	// EventReasonSuspended is the reason of the Extension event emitted when the LUNs of the suspended shoot were
+	// unmapped from their iSCSI targets
+	EventReasonSuspended = "Suspended"
 
-	// ImageCSIResizer is the image for the CSI resizer
-	ImageCSIResizer = "registry.k8s.io/sig-storage/csi-resizer:v1.12.0"
+	// EventReasonResumed is the reason of the Extension event emitted when the LUNs of the shoot were mapped to their
+	// iSCSI targets again after the suspension
+	EventReasonResumed = "Resumed"
 
-	// ImageCSISnapshotter is the image for the CSI snapshotter
-	ImageCSISnapshotter = "registry.k8s.io/sig-storage/csi-snapshotter:v8.1.0"
+	// EventReasonManifestsApplied is the reason of the Extension event emitted when the CSI driver manifests were applied
+	EventReasonManifestsApplied = "ManifestsApplied"
 
-	// ImageCSINodeDriverRegistrar is the image for the CSI node driver registrar
-	ImageCSINodeDriverRegistrar = "registry.k8s.io/sig-storage/csi-node-driver-registrar:v2.12.0"
+	// EventReasonDSMUnreachable is the reason of the Extension event emitted when the DSM API cannot be reached
+	EventReasonDSMUnreachable = "DSMUnreachable"
 
-	// ImageCSILivenessProbe is the image for the CSI liveness probe
-	ImageCSILivenessProbe = "registry.k8s.io/sig-storage/livenessprobe:v2.14.0"
+	// EventReasonUnknownWorkerPools is the reason of the Extension event emitted when worker pools of the node plugin
+	// do not exist in the shoot and are ignored
+	EventReasonUnknownWorkerPools = "UnknownWorkerPools"
+
+	// AnnotationDebugUntil is the Shoot annotation enabling the debug log output of the CSI driver and its sidecars
+	// until the given RFC3339 timestamp
+	AnnotationDebugUntil = ExtensionType + ".metal-stack.io/debug-until"
+
+	// DefaultDriverLogLevel is the default log level of the Synology CSI driver
+	DefaultDriverLogLevel = "info"
+
+	// DefaultSidecarVerbosity is the default klog verbosity of the CSI sidecars
+	DefaultSidecarVerbosity = 2
+
+	// DebugDriverLogLevel is the log level of the Synology CSI driver in debug mode
+	DebugDriverLogLevel = "debug"
+
+	// DebugSidecarVerbosity is the klog verbosity of the CSI sidecars in debug mode
+	DebugSidecarVerbosity = 5
+
+	// ImageNameCSIDriver is the name of the Synology CSI driver image in the image vector
+	ImageNameCSIDriver = "synology-csi"
+
+	// ImageNameCSIProvisioner is the name of the CSI provisioner image in the image vector
+	ImageNameCSIProvisioner = "csi-provisioner"
+
+	// ImageNameCSIAttacher is the name of the CSI attacher image in the image vector
+	ImageNameCSIAttacher = "csi-attacher"
+
+	// ImageNameCSIResizer is the name of the CSI resizer image in the image vector
+	ImageNameCSIResizer = "csi-resizer"
+
+	// ImageNameCSISnapshotter is the name of the CSI snapshotter image in the image vector
+	ImageNameCSISnapshotter = "csi-snapshotter"
+
+	// ImageNameCSINodeDriverRegistrar is the name of the CSI node driver registrar image in the image vector
+	ImageNameCSINodeDriverRegistrar = "csi-node-driver-registrar"
+
+	// ImageNameCSILivenessProbe is the name of the CSI liveness probe image in the image vector
+	ImageNameCSILivenessProbe = "csi-liveness-probe"
 
 	SynologySecretAdminUserRef     = "adminUser"
 	SynologySecretAdminPasswordRef = "adminPassword"
@@ -73,3 +163,45 @@ const (
 	SynologySecretShootUserRef     = "user"
 	SynologySecretShootPasswordRef = "password"
 )
+
+// ImageNames are the names of all images deployed by the extension
+var ImageNames = []string{
+	ImageNameCSIDriver,
+	ImageNameCSIProvisioner,
+	ImageNameCSIAttacher,
+	ImageNameCSIResizer,
+	ImageNameCSISnapshotter,
+	ImageNameCSINodeDriverRegistrar,
+	ImageNameCSILivenessProbe,
+}
+
+// ControllerContainerNames are the names of the containers of the CSI controller
+var ControllerContainerNames = []string{
+	"synology-csi-driver",
+	"csi-provisioner",
+	"csi-attacher",
+	"csi-resizer",
+	"csi-snapshotter",
+	"liveness-probe",
+}
+
+// NodeContainerNames are the names of the containers of the CSI node plugin
+var NodeContainerNames = []string{
+	"synology-csi-driver",
+	"csi-node-driver-registrar",
+	"liveness-probe",
+}
+
+// SnapshotClassDeletionPolicies are the supported deletion policies of the VolumeSnapshotClass
+var SnapshotClassDeletionPolicies = []string{
+	"Delete",
+	"Retain",
+}
+
+// DriverLogLevels are the supported log levels of the Synology CSI driver
+var DriverLogLevels = []string{
+	"debug",
+	"info",
+	"warn",
+	"error",
+}
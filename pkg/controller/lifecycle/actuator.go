@@ -3,25 +3,40 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	extensionsconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
 	"github.com/gardener/gardener/extensions/pkg/controller"
-	"github.com/gardener/gardener/extensions/pkg/controller/extension"
 	gutil "github.com/gardener/gardener/extensions/pkg/util"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	"github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"github.com/gardener/gardener/pkg/client/kubernetes"
 	"github.com/gardener/gardener/pkg/extensions"
+	gardenerutils "github.com/gardener/gardener/pkg/utils/gardener"
+	imagevectorutils "github.com/gardener/gardener/pkg/utils/imagevector"
 	"github.com/gardener/gardener/pkg/utils/managedresources"
 	"github.com/go-logr/logr"
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
-	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/v1alpha1"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	csidriversynologyv1alpha1 "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/v1alpha1"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/validation"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/imagevector"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -30,23 +45,35 @@ import (
 
 // Actuator acts upon Extension resources
 type Actuator struct {
-	client  client.Client
-	decoder runtime.Decoder
-	config  config.ControllerConfiguration
+	client      client.Client
+	decoder     runtime.Decoder
+	recorder    record.EventRecorder
+	config      config.ControllerConfiguration
+	imageVector imagevectorutils.ImageVector
+	clients     *synology.ClientManager
+	users       *managedUsersCounter
+	resolver    synology.Resolver
+
+	userCleanupQueue *userCleanupQueue
 }
 
 // NewActuator creates a new Actuator
-func NewActuator(client client.Client, config config.ControllerConfiguration) extension.Actuator {
+func NewActuator(log logr.Logger, client client.Client, recorder record.EventRecorder, config config.ControllerConfiguration) *Actuator {
 	return &Actuator{
-		client:  client,
-		decoder: serializer.NewCodecFactory(client.Scheme(), serializer.EnableStrict).UniversalDecoder(),
-		config:  config,
+		client:      client,
+		decoder:     serializer.NewCodecFactory(client.Scheme(), serializer.EnableStrict).UniversalDecoder(),
+		recorder:    recorder,
+		config:      config,
+		imageVector: imagevector.WithOverwrites(log, imagevector.ImageVector(), config.Images),
+		clients:     synology.NewClientManager(clientLimits(config.SynologyConfig)),
+		users:       newManagedUsersCounter(managedUsersInterval),
+		resolver:    net.DefaultResolver,
 	}
 }
 
 // Reconcile the Extension resource
 func (a *Actuator) Reconcile(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	shootConfig := &v1alpha1.CsiDriverSynologyConfig{}
+	shootConfig := &csidriversynology.CsiDriverSynologyConfig{}
 	if ex.Spec.ProviderConfig != nil {
 		_, _, err := a.decoder.Decode(ex.Spec.ProviderConfig.Raw, nil, shootConfig)
 		if err != nil {
@@ -65,134 +92,333 @@ func (a *Actuator) Reconcile(ctx context.Context, log logr.Logger, ex *extension
 		return err
 	}
 
-	secret, err := a.getAdminSynologySecret(ctx, cluster, a.config.SynologyConfig.SecretRef)
+	if errs := validation.ValidateCsiDriverSynologyConfig(shootConfig); len(errs) > 0 {
+		return fmt.Errorf("invalid provider config: %w", errs.ToAggregate())
+	}
+
+	if controller.IsHibernationEnabled(cluster) {
+		return a.reconcileHibernated(ctx, log, ex, cluster)
+	}
+
+	if unknown := dropUnknownWorkerPools(shootConfig, cluster.Shoot.Spec.Provider.Workers); len(unknown) > 0 {
+		a.recorder.Eventf(ex, corev1.EventTypeWarning, constants.EventReasonUnknownWorkerPools, "Ignoring worker pools %s of the node plugin which do not exist in the shoot", strings.Join(unknown, ", "))
+		log.Info("Ignoring worker pools of the node plugin which do not exist in the shoot", "workerPools", unknown)
+	}
+
+	clients, err := a.loginAll(ctx, ex, cluster)
 	if err != nil {
 		return err
 	}
 
-	adminUsername, adminPassword, err := extractAdminSynologySecret(secret)
-	if err != nil {
+	shootUsername := synology.GenerateShootUsername(shootName, shootNamespace)
+	shootPassword := ""
+
+	var credentialsRotatedAt *metav1.Time
+
+	users := make([]*synology.User, len(clients))
+	for i, c := range clients {
+		users[i], err = c.client.GetUser(ctx, shootUsername)
+		if err != nil {
+			return fmt.Errorf("failed to get user from Synology NAS %s: %w", c.url, err)
+		}
+	}
+
+	// the DSM user has the same credentials on all NAS, existing users keep their password
+	if slices.ContainsFunc(users, func(user *synology.User) bool { return user != nil }) {
+		shootPassword, err = a.shootPassword(ctx, ex.Namespace)
+		if err != nil {
+			return err
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonCredentialsReused, "Reusing existing DSM user %s and its credentials", shootUsername)
+	} else {
+		shootPassword, err = synology.GenerateRandomPassword(16)
+		if err != nil {
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+
+		credentialsRotatedAt = ptr.To(metav1.Now())
+	}
+
+	for i, c := range clients {
+		if users[i] != nil {
+			continue
+		}
+
+		if err := c.client.CreateUser(ctx, shootUsername, shootPassword); err != nil {
+			return fmt.Errorf("failed to create user on Synology NAS %s: %w", c.url, err)
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonUserCreated, "Created DSM user %s on %s", shootUsername, c.url)
+	}
+
+	if err := a.reconcileSeedCredentials(ctx, ex.Namespace, shootUsername, shootPassword); err != nil {
 		return err
 	}
 
-	// Create Synology client
-	synologyClient, err := synology.NewClient(
-		a.config.SynologyConfig.URL,
-		adminUsername,
-		adminPassword,
-	)
+	for i, c := range clients {
+		disabled := users[i] != nil && users[i].Disabled()
+		if shootConfig.Suspended {
+			err = a.suspend(ctx, log, ex, c, shootUsername, disabled)
+		} else {
+			err = a.resume(ctx, log, ex, c, shootUsername, disabled)
+		}
+		if err != nil {
+			return err
+		}
+	}
 
+	for _, c := range clients {
+		a.users.count(ctx, log, c, time.Now())
+	}
+
+	logLevel, verbosity, debugUntil := logOutput(log, shootConfig.Logging, cluster.Shoot, time.Now())
+	if debugUntil != nil {
+		log.Info("Debug mode enabled", "until", debugUntil)
+	}
+
+	manifestConfig, err := a.newManifestConfig(ctx, log, cluster, shootConfig, shootUsername, shootPassword, logLevel, verbosity)
 	if err != nil {
-		return fmt.Errorf("failed to create Synology client: %w", err)
+		return err
 	}
 
-	if err := synologyClient.Login(); err != nil {
-		return fmt.Errorf("failed to login to Synology NAS: %w", err)
+	manifestConfig.Snapshots, err = a.ensureSnapshotAPI(ctx, log, ex)
+	if err != nil {
+		return err
 	}
-	defer synologyClient.Logout()
 
-	shootUsername := synology.GenerateShootUsername(shootName, shootNamespace)
-	shootPassword := ""
+	// the scheduler only considers nodes with a published capacity, so tracking waits for the capacity of every
+	// storage class
+	manifestConfig.StorageCapacity = a.config.CapacityRefreshInterval != nil && capacityPublished(ex)
 
-	user, err := synologyClient.GetUser(shootUsername)
+	objects, err := a.generateManifests(manifestConfig)
 	if err != nil {
-		return fmt.Errorf("failed to get user from Synology: %w", err)
+		return fmt.Errorf("unable to generate resource manifests for shoot: %w", err)
 	}
 
-	if user == nil {
-		shootPassword, err = synology.GenerateRandomPassword(16)
-		if err != nil {
-			return fmt.Errorf("failed to generate password: %w", err)
+	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(objects...)
+	if err != nil {
+		return fmt.Errorf("unable to create registry: %w", err)
+	}
+
+	err = managedresources.CreateForShoot(ctx, a.client, ex.Namespace, constants.CSIDriverName, constants.ExtensionType, false, shootResources)
+	if err != nil {
+		return fmt.Errorf("unable to create shoot resources: %w", err)
+	}
+
+	if a.config.CapacityRefreshInterval == nil {
+		if err := a.deleteCapacity(ctx, ex); err != nil {
+			return err
 		}
+	}
 
-		if err := synologyClient.CreateUser(shootUsername, shootPassword); err != nil {
-			return fmt.Errorf("failed to create user on Synology: %w", err)
+	if a.config.ControllerInSeed {
+		if err := a.deploySeedController(ctx, cluster, ex.Namespace, manifestConfig); err != nil {
+			return err
 		}
 	} else {
-		secret, err := a.getShootSynologySecret(ctx, ex.Namespace)
-		if err != nil {
+		if err := a.deleteSeedController(ctx, ex.Namespace); err != nil {
 			return err
 		}
+	}
 
-		_, shootPwd, err := extractShootSynologySecret(secret)
-		if err != nil {
+	a.recorder.Event(ex, corev1.EventTypeNormal, constants.EventReasonManifestsApplied, "Applied the Synology CSI driver manifests")
+
+	dsmVersion, err := clients[0].client.DSMVersion(ctx)
+	if err != nil {
+		log.Error(err, "Unable to get the DSM version of the Synology NAS")
+	}
+
+	if err := a.updateProviderStatus(ctx, ex, &csidriversynology.CsiDriverSynologyStatus{
+		Username:               shootUsername,
+		Endpoint:               a.config.SynologyConfig.URL,
+		DSMVersion:             dsmVersion,
+		StorageClasses:         storageClassNames(objects),
+		LastCredentialRotation: credentialsRotatedAt,
+		DebugUntil:             debugUntil,
+	}); err != nil {
+		return err
+	}
+
+	log.Info("Successfully reconciled Synology CSI extension")
+	return nil
+}
+
+// Delete removes the CSI driver from the shoot and the seed and deletes the DSM user of the shoot on every NAS
+func (a *Actuator) Delete(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
+	cluster, err := controller.GetCluster(ctx, a.client, ex.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// the API server of a hibernated shoot is down, so the objects are left behind like on a forced deletion
+	hibernated := controller.IsHibernated(cluster)
+	if err := a.deleteShootResources(ctx, ex.Namespace, hibernated); err != nil {
+		return err
+	}
+
+	if !hibernated {
+		if err := a.waitUntilShootResourcesDeleted(ctx, ex.Namespace); err != nil {
 			return err
 		}
+	}
+
+	if err := a.deleteSeedController(ctx, ex.Namespace); err != nil {
+		return err
+	}
 
-		shootPassword = shootPwd
+	if err := a.deleteUser(ctx, log, ex, cluster); err != nil {
+		return err
 	}
 
+	return a.deleteSeedCredentials(ctx, ex.Namespace)
+}
+
+// dropUnknownWorkerPools removes the worker pools which do not exist in the shoot from the node plugin config and
+// returns them. If none of the pools exists anymore, the node plugin is placed on all pools.
+func dropUnknownWorkerPools(shootConfig *csidriversynology.CsiDriverSynologyConfig, workers []gardencorev1beta1.Worker) []string {
+	if shootConfig.NodePlugin == nil {
+		return nil
+	}
+
+	var known, unknown []string
+	for _, pool := range shootConfig.NodePlugin.WorkerPools {
+		if slices.ContainsFunc(workers, func(w gardencorev1beta1.Worker) bool { return w.Name == pool }) {
+			known = append(known, pool)
+		} else {
+			unknown = append(unknown, pool)
+		}
+	}
+
+	shootConfig.NodePlugin.WorkerPools = known
+	return unknown
+}
+
+// newManifestConfig returns the configuration of the CSI driver manifests of the given shoot, the DSM user with the
+// given credentials is used by the CSI driver
+func (a *Actuator) newManifestConfig(ctx context.Context, log logr.Logger, cluster *extensions.Cluster, shootConfig *csidriversynology.CsiDriverSynologyConfig, username, password, logLevel string, verbosity int32) (*synology.ManifestConfig, error) {
 	u, err := url.Parse(a.config.SynologyConfig.URL)
 	if err != nil {
-		return fmt.Errorf("failed to parse synology-url: %w", err)
+		return nil, fmt.Errorf("failed to parse synology-url: %w", err)
 	}
 
 	port, err := strconv.Atoi(u.Port())
 	if err != nil {
-		return fmt.Errorf("failed to parse synology-url port: %w", err)
+		return nil, fmt.Errorf("failed to parse synology-url port: %w", err)
 	}
 
-	// Create manifest config
+	images, err := imagevector.FindImages(a.imageVector, cluster.Shoot.Spec.Kubernetes.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find images for shoot: %w", err)
+	}
+
+	replicas, topologyKey := a.controllerHighAvailability(cluster)
+
 	manifestConfig := &synology.ManifestConfig{
 		Namespace: constants.ShootTargetNamespace,
 		Url:       a.config.SynologyConfig.URL,
-		Username:  shootUsername,
-		Password:  shootPassword,
+		Username:  username,
+		Password:  password,
 		Clients: []synology.ClientConfig{
 			{
 				Host:     u.Hostname(),
 				Port:     port,
 				HTTPS:    u.Scheme == "https",
-				Username: shootUsername,
-				Password: shootPassword,
+				Username: username,
+				Password: password,
 			},
 			{
 				Host:     u.Hostname(),
 				Port:     5001,
 				HTTPS:    u.Scheme == "https",
-				Username: shootUsername,
-				Password: shootPassword,
+				Username: username,
+				Password: password,
 			},
 		},
+		Images:                images,
+		ControllerReplicas:    replicas,
+		Suspended:             shootConfig.Suspended,
+		ControllerTopologyKey: topologyKey,
+		VPAEnabled:            helper.ShootWantsVerticalPodAutoscaler(cluster.Shoot),
+		DriverLogLevel:        logLevel,
+		SidecarVerbosity:      verbosity,
+
+		Snapshots:                   true,
+		SnapshotClassDeletionPolicy: a.config.SynologyConfig.SnapshotClass.DeletionPolicy,
+		SnapshotClassParameters:     a.config.SynologyConfig.SnapshotClass.Parameters,
 	}
 
-	objects, err := a.generateManifests(manifestConfig)
-	if err != nil {
-		return fmt.Errorf("unable to generate resource manifests for shoot: %w", err)
-	}
+	manifestConfig.TopologyKey, manifestConfig.TopologyValues = topology(a.config.SynologyConfig.Topology)
 
-	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(objects...)
-	if err != nil {
-		return fmt.Errorf("unable to create registry: %w", err)
+	for _, nas := range a.config.SynologyConfig.AdditionalNAS {
+		nasURL, err := url.Parse(nas.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url of NAS %s: %w", nas.Name, err)
+		}
+
+		nasPort := 5000
+		if nasURL.Scheme == "https" {
+			nasPort = 5001
+		}
+		if nasURL.Port() != "" {
+			if nasPort, err = strconv.Atoi(nasURL.Port()); err != nil {
+				return nil, fmt.Errorf("failed to parse port of NAS %s: %w", nas.Name, err)
+			}
+		}
+
+		manifestConfig.Clients = append(manifestConfig.Clients, synology.ClientConfig{
+			Host:     nasURL.Hostname(),
+			Port:     nasPort,
+			HTTPS:    nasURL.Scheme == "https",
+			Username: username,
+			Password: password,
+		})
+
+		location := nas.Location
+		if location == "" {
+			location = constants.DefaultVolumeLocation
+		}
+
+		nasConfig := synology.NASConfig{
+			Name:     nas.Name,
+			Host:     nasURL.Hostname(),
+			Location: location,
+		}
+		nasConfig.TopologyKey, nasConfig.TopologyValues = topology(nas.Topology)
+		manifestConfig.AdditionalNAS = append(manifestConfig.AdditionalNAS, nasConfig)
 	}
 
-	err = managedresources.CreateForShoot(ctx, a.client, ex.Namespace, constants.CSIDriverName, constants.ExtensionType, false, shootResources)
-	if err != nil {
-		return fmt.Errorf("unable to create shoot resources: %w", err)
+	dsmEgressCIDRs, unresolved := synology.ResolveDSMEgressCIDRs(ctx, a.resolver, manifestConfig.Clients)
+	if len(unresolved) > 0 {
+		log.Info("Unable to resolve DSM hosts, opening the DSM ports to any destination", "hosts", unresolved)
 	}
+	manifestConfig.DSMEgressCIDRs = dsmEgressCIDRs
+	manifestConfig.AdditionalEgressCIDRs = a.config.AdditionalEgressCIDRs
 
-	log.Info("Successfully reconciled Synology CSI extension")
-	return nil
-}
+	if a.config.Resources != nil {
+		manifestConfig.ControllerResources = a.config.Resources.Controller
+		manifestConfig.NodeResources = a.config.Resources.Node
+	}
 
-// Delete the Extension resource
-func (a *Actuator) Delete(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	return nil
-}
+	if shootConfig.NodePlugin != nil {
+		manifestConfig.NodeWorkerPools = shootConfig.NodePlugin.WorkerPools
+		manifestConfig.NodeTolerations = shootConfig.NodePlugin.Tolerations
+	}
 
-// Restore the Extension resource
-func (a *Actuator) Restore(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	return a.Reconcile(ctx, log, ex)
+	return manifestConfig, nil
 }
 
-// Migrate the Extension resource
-func (a *Actuator) Migrate(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	return nil
-}
+// topology returns the node label and its values of the nodes which can reach a NAS with the given topology
+func topology(topology *config.SynologyTopology) (string, []string) {
+	if topology == nil {
+		return "", nil
+	}
 
-// ForceDelete forcefully deletes the Extension resource
-func (a *Actuator) ForceDelete(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
-	return nil
+	key := topology.Key
+	if key == "" {
+		key = corev1.LabelTopologyZone
+	}
+	return key, topology.Values
 }
 
 // generateManifests deploys all necessary resources to the shoot cluster
@@ -203,24 +429,263 @@ func (a *Actuator) generateManifests(config *synology.ManifestConfig) ([]client.
 	}
 
 	objects := []client.Object{
-		synology.GenerateServiceAccount(config.Namespace, constants.ControllerName),
 		synology.GenerateServiceAccount(config.Namespace, constants.NodeName),
 		synology.GenerateControllerClusterRole(),
 		synology.GenerateNodeClusterRole(),
 		synology.GenerateClusterRoleBinding(constants.ControllerName, config.Namespace, constants.ControllerName),
 		synology.GenerateClusterRoleBinding(constants.NodeName, config.Namespace, constants.NodeName),
+		synology.GenerateControllerLeaderElectionRole(config.Namespace),
+		synology.GenerateRoleBinding(constants.ControllerName, config.Namespace, constants.ControllerName),
 		secret,
-		synology.GenerateCSIDriver(),
-		synology.GenerateService(config.Namespace),
-		synology.GenerateControllerDeployment(config.Namespace),
-		synology.GenerateNodeDaemonSet(config.Namespace),
-		synology.GenerateStorageClass(config.Namespace),
-		synology.GenerateAllowAllEgressNetworkPolicy(config.Namespace),
+		synology.GenerateCSIDriver(config),
+		synology.GenerateNodeDaemonSet(config),
+		synology.GenerateEgressNetworkPolicy(config),
+	}
+
+	for _, storageClass := range synology.GenerateStorageClasses(config) {
+		objects = append(objects, storageClass)
+	}
+
+	if config.Snapshots {
+		objects = append(objects, synology.GenerateVolumeSnapshotClass(config))
+	}
+
+	// in seed mode the controller's service account is created by the token requestor of the shoot access secret
+	if !a.config.ControllerInSeed {
+		objects = append(objects,
+			synology.GenerateServiceAccount(config.Namespace, constants.ControllerName),
+			synology.GenerateService(config.Namespace),
+			synology.GenerateControllerDeployment(config),
+			synology.GenerateControllerPodDisruptionBudget(config.Namespace),
+		)
+
+		if config.VPAEnabled {
+			objects = append(objects, synology.GenerateControllerVPA(config.Namespace))
+		}
+	}
+
+	if config.VPAEnabled {
+		objects = append(objects, synology.GenerateNodeVPA(config.Namespace))
 	}
 
 	return objects, nil
 }
 
+// controllerHighAvailability returns the replica count and the anti-affinity topology key of the CSI controller
+// based on the configuration and the shoot's control plane failure tolerance.
+func (a *Actuator) controllerHighAvailability(cluster *extensions.Cluster) (int32, string) {
+	replicas := int32(1)
+	if helper.IsHAControlPlaneConfigured(cluster.Shoot) {
+		replicas = 2
+	}
+	if a.config.ControllerReplicas != nil {
+		replicas = *a.config.ControllerReplicas
+	}
+
+	topologyKey := corev1.LabelHostname
+	if helper.IsMultiZonalShootControlPlane(cluster.Shoot) {
+		topologyKey = corev1.LabelTopologyZone
+	}
+
+	return replicas, topologyKey
+}
+
+// deploySeedController deploys the CSI controller into the shoot's control plane namespace in the seed
+func (a *Actuator) deploySeedController(ctx context.Context, cluster *extensions.Cluster, namespace string, config *synology.ManifestConfig) error {
+	shootAccessSecret := gardenerutils.NewShootAccessSecret(constants.ControllerName, namespace)
+	if err := shootAccessSecret.Reconcile(ctx, a.client); err != nil {
+		return fmt.Errorf("unable to reconcile shoot access secret: %w", err)
+	}
+
+	objects, err := seedControllerObjects(cluster, namespace, config)
+	if err != nil {
+		return err
+	}
+
+	seedResources, err := managedresources.NewRegistry(kubernetes.SeedScheme, kubernetes.SeedCodec, kubernetes.SeedSerializer).AddAllAndSerialize(objects...)
+	if err != nil {
+		return fmt.Errorf("unable to create registry: %w", err)
+	}
+
+	err = managedresources.CreateForSeed(ctx, a.client, namespace, constants.SeedManagedResourceName, false, seedResources)
+	if err != nil {
+		return fmt.Errorf("unable to create seed resources: %w", err)
+	}
+
+	return nil
+}
+
+// seedControllerObjects returns the objects of the CSI controller deployed into the shoot's control plane namespace
+func seedControllerObjects(cluster *extensions.Cluster, namespace string, config *synology.ManifestConfig) ([]client.Object, error) {
+	seedConfig := *config
+	seedConfig.Namespace = namespace
+	seedConfig.VPAEnabled = cluster.Seed != nil && helper.SeedSettingVerticalPodAutoscalerEnabled(cluster.Seed.Spec.Settings)
+
+	secret, err := synology.GenerateSecret(&seedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	deployment, err := synology.GenerateSeedControllerDeployment(&seedConfig, extensions.GenericTokenKubeconfigSecretNameFromCluster(cluster))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate controller deployment: %w", err)
+	}
+
+	objects := []client.Object{
+		secret,
+		deployment,
+		synology.GenerateControllerPodDisruptionBudget(namespace),
+	}
+
+	if seedConfig.VPAEnabled {
+		objects = append(objects, synology.GenerateControllerVPA(namespace))
+	}
+
+	return objects, nil
+}
+
+// deleteSeedController removes the CSI controller and its shoot access secret from the seed
+func (a *Actuator) deleteSeedController(ctx context.Context, namespace string) error {
+	if err := managedresources.DeleteForSeed(ctx, a.client, namespace, constants.SeedManagedResourceName); err != nil {
+		return fmt.Errorf("unable to delete seed resources: %w", err)
+	}
+
+	shootAccessSecret := gardenerutils.NewShootAccessSecret(constants.ControllerName, namespace)
+	if err := client.IgnoreNotFound(a.client.Delete(ctx, shootAccessSecret.Secret)); err != nil {
+		return fmt.Errorf("unable to delete shoot access secret: %w", err)
+	}
+
+	return nil
+}
+
+// ensureSnapshotAPI checks whether the shoot serves the volume snapshot API the VolumeSnapshotClass and the
+// csi-snapshotter rely on and reports the result as a condition on the Extension. The CSI driver is deployed without
+// snapshot support if the API is missing.
+func (a *Actuator) ensureSnapshotAPI(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) (bool, error) {
+	_, shootClient, err := gutil.NewClientForShoot(ctx, a.client, ex.Namespace, client.Options{}, extensionsconfigv1alpha1.RESTOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create shoot client: %w", err)
+	}
+
+	var (
+		available = true
+		status    = gardencorev1beta1.ConditionTrue
+		reason    = "SnapshotAPIAvailable"
+		message   = "The shoot serves the volume snapshot API."
+	)
+
+	gvk := volumesnapshotv1.SchemeGroupVersion.WithKind("VolumeSnapshotClass")
+	if _, err := shootClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if !meta.IsNoMatchError(err) {
+			return false, fmt.Errorf("failed to discover volume snapshot api in shoot: %w", err)
+		}
+
+		available = false
+		status = gardencorev1beta1.ConditionFalse
+		reason = "SnapshotAPIMissing"
+		message = fmt.Sprintf("The shoot does not serve %s, the snapshot CRDs and the snapshot-controller need to be installed. The CSI driver is deployed without snapshot support.", gvk.GroupVersion())
+		log.Info("Volume snapshot API missing in the shoot, deploying the CSI driver without snapshot support", "groupVersion", gvk.GroupVersion().String())
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	condition := helper.GetOrInitConditionWithClock(clock.RealClock{}, ex.Status.Conditions, constants.ConditionTypeSnapshotAPIAvailable)
+	condition = helper.UpdatedConditionWithClock(clock.RealClock{}, condition, status, reason, message)
+	ex.Status.Conditions = helper.MergeConditions(ex.Status.Conditions, condition)
+	if err := a.client.Status().Patch(ctx, ex, patch); err != nil {
+		return false, fmt.Errorf("failed to update extension conditions: %w", err)
+	}
+
+	return available, nil
+}
+
+// updateProviderStatus records the given status as provider status of the Extension, keeping the time of the last
+// credential rotation if the credentials were not rotated in this reconciliation
+func (a *Actuator) updateProviderStatus(ctx context.Context, ex *extensionsv1alpha1.Extension, status *csidriversynology.CsiDriverSynologyStatus) error {
+	if status.LastCredentialRotation == nil && ex.Status.ProviderStatus != nil {
+		previous := &csidriversynology.CsiDriverSynologyStatus{}
+		if _, _, err := a.decoder.Decode(ex.Status.ProviderStatus.Raw, nil, previous); err == nil {
+			status.LastCredentialRotation = previous.LastCredentialRotation
+		}
+	}
+
+	providerStatus := &csidriversynologyv1alpha1.CsiDriverSynologyStatus{}
+	if err := a.client.Scheme().Convert(status, providerStatus, nil); err != nil {
+		return fmt.Errorf("failed to convert provider status: %w", err)
+	}
+	providerStatus.SetGroupVersionKind(csidriversynologyv1alpha1.SchemeGroupVersion.WithKind("CsiDriverSynologyStatus"))
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Status.ProviderStatus = &runtime.RawExtension{Object: providerStatus}
+	if err := a.client.Status().Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to update extension provider status: %w", err)
+	}
+
+	return nil
+}
+
+// storageClassNames returns the names of the storage classes among the given objects
+func storageClassNames(objects []client.Object) []string {
+	var names []string
+	for _, obj := range objects {
+		if sc, ok := obj.(*storagev1.StorageClass); ok {
+			names = append(names, sc.Name)
+		}
+	}
+	return names
+}
+
+// clientLimits returns the limits of the requests to the NAS, unset fields keep their defaults
+func clientLimits(cfg config.SynologyConfiguration) synology.Limits {
+	limits := synology.DefaultLimits
+
+	if rateLimit := cfg.RateLimit; rateLimit != nil {
+		if rateLimit.RequestsPerSecond != nil {
+			limits.RequestsPerSecond = float64(*rateLimit.RequestsPerSecond)
+		}
+		if rateLimit.Burst != nil {
+			limits.Burst = int(*rateLimit.Burst)
+		}
+		if rateLimit.MaxConcurrent != nil {
+			limits.MaxConcurrent = int(*rateLimit.MaxConcurrent)
+		}
+	}
+
+	if retry := cfg.Retry; retry != nil {
+		if retry.MaxRetries != nil {
+			limits.MaxRetries = int(*retry.MaxRetries)
+		}
+		if retry.InitialBackoff != nil {
+			limits.InitialBackoff = retry.InitialBackoff.Duration
+		}
+		if retry.MaxBackoff != nil {
+			limits.MaxBackoff = retry.MaxBackoff.Duration
+		}
+	}
+
+	return limits
+}
+
+// newAdminClient returns the shared client for the DSM API of the given NAS with the admin credentials referenced by
+// the shoot
+func (a *Actuator) newAdminClient(ctx context.Context, cluster *extensions.Cluster, n nas) (*synology.Client, error) {
+	secret, err := a.getAdminSynologySecret(ctx, cluster, n.secretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	adminUsername, adminPassword, err := extractAdminSynologySecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	synologyClient, err := a.clients.Get(n.url, adminUsername, adminPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Synology client: %w", err)
+	}
+
+	return synologyClient, nil
+}
+
 func (a *Actuator) getAdminSynologySecret(ctx context.Context, cluster *extensions.Cluster, secretName string) (*corev1.Secret, error) {
 	fromShootResources := func() (*corev1.Secret, error) {
 		secretRef := helper.GetResourceByName(cluster.Shoot.Spec.Resources, secretName)
@@ -0,0 +1,458 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	csidriversynologyinstall "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/install"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology/fake"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testNamespace         = "shoot--project--name"
+	testCleanupNamespace  = "garden"
+	testAdmin             = "admin"
+	testAdminPassword     = "admin-password"
+	testAdminSecretPrefix = "synology-admin"
+)
+
+var testUsername = synology.GenerateShootUsername(testNamespace, testNamespace)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := extensionscontroller.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := csidriversynologyinstall.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// newTestNAS starts fake DSMs with the admin credentials of the tests
+func newTestNAS(t *testing.T, count int) []*fake.Server {
+	t.Helper()
+
+	servers := make([]*fake.Server, count)
+	for i := range servers {
+		servers[i] = fake.NewServer(testAdmin, testAdminPassword)
+		t.Cleanup(servers[i].Close)
+	}
+	return servers
+}
+
+// testConfig returns the configuration of the extension for the given NAS, further NAS are named nas-<index>
+func testConfig(servers []*fake.Server) config.ControllerConfiguration {
+	cfg := config.ControllerConfiguration{
+		SynologyConfig: config.SynologyConfiguration{
+			URL:       servers[0].URL,
+			SecretRef: testAdminSecretPrefix + "-0",
+		},
+	}
+	for i, server := range servers[1:] {
+		cfg.SynologyConfig.AdditionalNAS = append(cfg.SynologyConfig.AdditionalNAS, config.SynologyNAS{
+			Name:      fmt.Sprintf("nas-%d", i+1),
+			URL:       server.URL,
+			SecretRef: fmt.Sprintf("%s-%d", testAdminSecretPrefix, i+1),
+		})
+	}
+	return cfg
+}
+
+// testShoot returns the shoot of the tests referencing the admin secrets of the given number of NAS
+func testShoot(nasCount int) *gardencorev1beta1.Shoot {
+	shoot := &gardencorev1beta1.Shoot{
+		TypeMeta:   metav1.TypeMeta{APIVersion: gardencorev1beta1.SchemeGroupVersion.String(), Kind: "Shoot"},
+		ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "garden-project"},
+		Spec: gardencorev1beta1.ShootSpec{
+			Kubernetes: gardencorev1beta1.Kubernetes{Version: "1.33.0"},
+		},
+	}
+	for i := range nasCount {
+		name := fmt.Sprintf("%s-%d", testAdminSecretPrefix, i)
+		shoot.Spec.Resources = append(shoot.Spec.Resources, gardencorev1beta1.NamedResourceReference{
+			Name:        name,
+			ResourceRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "v1", Kind: "Secret", Name: name},
+		})
+	}
+	return shoot
+}
+
+// hibernate marks the given shoot as hibernated
+func hibernate(shoot *gardencorev1beta1.Shoot) *gardencorev1beta1.Shoot {
+	shoot.Spec.Hibernation = &gardencorev1beta1.Hibernation{Enabled: ptr.To(true)}
+	shoot.Status.IsHibernated = true
+	return shoot
+}
+
+// testSeedObjects returns the Cluster of the given shoot, the admin secrets it references and its Extension
+func testSeedObjects(t *testing.T, shoot *gardencorev1beta1.Shoot) []client.Object {
+	t.Helper()
+
+	raw, err := json.Marshal(shoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects := []client.Object{
+		&extensionsv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: testNamespace},
+			Spec: extensionsv1alpha1.ClusterSpec{
+				Shoot:        runtime.RawExtension{Raw: raw},
+				Seed:         runtime.RawExtension{Raw: []byte(`{"apiVersion":"core.gardener.cloud/v1beta1","kind":"Seed"}`)},
+				CloudProfile: runtime.RawExtension{Raw: []byte(`{"apiVersion":"core.gardener.cloud/v1beta1","kind":"CloudProfile"}`)},
+			},
+		},
+		testExtension(),
+	}
+	for _, ref := range shoot.Spec.Resources {
+		objects = append(objects, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: v1beta1constants.ReferencedResourcesPrefix + ref.Name, Namespace: testNamespace},
+			Data: map[string][]byte{
+				constants.SynologySecretAdminUserRef:     []byte(testAdmin),
+				constants.SynologySecretAdminPasswordRef: []byte(testAdminPassword),
+			},
+		})
+	}
+	return objects
+}
+
+func testExtension() *extensionsv1alpha1.Extension {
+	return &extensionsv1alpha1.Extension{
+		ObjectMeta: metav1.ObjectMeta{Name: constants.ExtensionType, Namespace: testNamespace},
+		Spec: extensionsv1alpha1.ExtensionSpec{
+			DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: constants.ExtensionType},
+		},
+	}
+}
+
+// newTestActuator returns an actuator for the given client whose DSM requests are not limited
+func newTestActuator(c client.Client, cfg config.ControllerConfiguration) (*Actuator, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+
+	a := NewActuator(logr.Discard(), c, recorder, cfg)
+	a.clients = synology.NewClientManager(synology.Limits{})
+	a.resolver = nil
+	a.userCleanupQueue = &userCleanupQueue{client: c, namespace: testCleanupNamespace}
+
+	return a, recorder
+}
+
+// recordedEvents returns the events recorded so far
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// countEvents returns the number of the given events with the given reason
+func countEvents(events []string, reason string) int {
+	count := 0
+	for _, event := range events {
+		if strings.Contains(event, " "+reason+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestReconcileUnreachableNAS(t *testing.T) {
+	ctx := context.Background()
+	servers := newTestNAS(t, 2)
+	servers[1].SetError("SYNO.API.Auth", "login", fake.ErrorCodeUnknown)
+
+	c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(testSeedObjects(t, testShoot(2))...).Build()
+
+	a, recorder := newTestActuator(c, testConfig(servers))
+
+	if err := a.Reconcile(ctx, logr.Discard(), testExtension()); err == nil {
+		t.Fatal("expected reconciliation to fail while a NAS is unreachable")
+	}
+
+	if _, ok := servers[1].User(testUsername); ok {
+		t.Error("expected no user to be created on the unreachable NAS")
+	}
+	if got := countEvents(recordedEvents(recorder), constants.EventReasonDSMUnreachable); got != 1 {
+		t.Errorf("expected 1 event of an unreachable DSM, got %d", got)
+	}
+}
+
+func TestReconcileMissingAdminSecret(t *testing.T) {
+	ctx := context.Background()
+	server := newTestNAS(t, 1)[0]
+
+	c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(testSeedObjects(t, testShoot(0))...).Build()
+
+	a, _ := newTestActuator(c, testConfig([]*fake.Server{server}))
+
+	if err := a.Reconcile(ctx, logr.Discard(), testExtension()); err == nil {
+		t.Fatal("expected reconciliation to fail without a referenced admin secret")
+	}
+	if logins := server.Requests("SYNO.API.Auth", "login"); logins != 0 {
+		t.Errorf("expected no login without admin credentials, got %d", logins)
+	}
+}
+
+func TestDropUnknownWorkerPools(t *testing.T) {
+	workers := []gardencorev1beta1.Worker{{Name: "default"}, {Name: "storage"}}
+
+	tests := []struct {
+		name        string
+		nodePlugin  *csidriversynology.NodePluginConfig
+		wantPools   []string
+		wantUnknown []string
+	}{
+		{
+			name: "no node plugin config",
+		},
+		{
+			name:       "known pools",
+			nodePlugin: &csidriversynology.NodePluginConfig{WorkerPools: []string{"storage"}},
+			wantPools:  []string{"storage"},
+		},
+		{
+			name:        "removed pool",
+			nodePlugin:  &csidriversynology.NodePluginConfig{WorkerPools: []string{"removed", "storage"}},
+			wantPools:   []string{"storage"},
+			wantUnknown: []string{"removed"},
+		},
+		{
+			name:        "all pools removed",
+			nodePlugin:  &csidriversynology.NodePluginConfig{WorkerPools: []string{"removed"}},
+			wantUnknown: []string{"removed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shootConfig := &csidriversynology.CsiDriverSynologyConfig{NodePlugin: tt.nodePlugin}
+
+			unknown := dropUnknownWorkerPools(shootConfig, workers)
+			if !slices.Equal(unknown, tt.wantUnknown) {
+				t.Errorf("expected unknown pools %v, got %v", tt.wantUnknown, unknown)
+			}
+			if tt.nodePlugin != nil && !slices.Equal(shootConfig.NodePlugin.WorkerPools, tt.wantPools) {
+				t.Errorf("expected pools %v, got %v", tt.wantPools, shootConfig.NodePlugin.WorkerPools)
+			}
+		})
+	}
+}
+
+func TestReconcileHibernated(t *testing.T) {
+	for _, disableUser := range []bool{true, false} {
+		t.Run(fmt.Sprintf("disableUserWhileHibernated=%t", disableUser), func(t *testing.T) {
+			ctx := context.Background()
+			servers := newTestNAS(t, 2)
+			for _, server := range servers {
+				server.AddUser(testUsername, "password", "users")
+			}
+
+			c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(testSeedObjects(t, hibernate(testShoot(2)))...).Build()
+
+			cfg := testConfig(servers)
+			cfg.DisableUserWhileHibernated = disableUser
+			a, recorder := newTestActuator(c, cfg)
+
+			if err := a.Reconcile(ctx, logr.Discard(), testExtension()); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			for _, server := range servers {
+				user, _ := server.User(testUsername)
+				if user.Expired != disableUser {
+					t.Errorf("expected user on %s to be disabled=%t", server.URL, disableUser)
+				}
+			}
+
+			want := 0
+			if disableUser {
+				want = len(servers)
+			}
+			if got := countEvents(recordedEvents(recorder), constants.EventReasonUserDisabled); got != want {
+				t.Errorf("expected %d events of disabled users, got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	servers := newTestNAS(t, 2)
+	for _, server := range servers {
+		server.AddUser(testUsername, "password", "users")
+	}
+
+	objects := append(testSeedObjects(t, testShoot(2)),
+		&resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Name: constants.CSIDriverName, Namespace: testNamespace}},
+		&resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Name: constants.SeedManagedResourceName, Namespace: testNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SeedCredentialsSecretName, Namespace: testNamespace}},
+	)
+	c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).Build()
+
+	a, recorder := newTestActuator(c, testConfig(servers))
+
+	if err := a.Delete(ctx, logr.Discard(), testExtension()); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	for _, server := range servers {
+		if _, ok := server.User(testUsername); ok {
+			t.Errorf("expected user on %s to be deleted", server.URL)
+		}
+	}
+	if got := countEvents(recordedEvents(recorder), constants.EventReasonUserDeleted); got != len(servers) {
+		t.Errorf("expected %d events of deleted users, got %d", len(servers), got)
+	}
+
+	for _, obj := range []client.Object{
+		&resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Name: constants.CSIDriverName, Namespace: testNamespace}},
+		&resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Name: constants.SeedManagedResourceName, Namespace: testNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SeedCredentialsSecretName, Namespace: testNamespace}},
+	} {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); !apierrors.IsNotFound(err) {
+			t.Errorf("expected %T %s to be deleted, got %v", obj, obj.GetName(), err)
+		}
+	}
+}
+
+func TestDeleteUnreachableNAS(t *testing.T) {
+	ctx := context.Background()
+	servers := newTestNAS(t, 2)
+	servers[1].AddUser(testUsername, "password", "users")
+	servers[1].SetError("SYNO.API.Auth", "login", fake.ErrorCodeUnknown)
+
+	c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(testSeedObjects(t, testShoot(2))...).Build()
+
+	a, recorder := newTestActuator(c, testConfig(servers))
+
+	if err := a.Delete(ctx, logr.Discard(), testExtension()); err == nil {
+		t.Fatal("expected deletion to fail while a NAS is unreachable")
+	}
+
+	if _, ok := servers[1].User(testUsername); !ok {
+		t.Error("expected user on the unreachable NAS to be kept")
+	}
+	if got := countEvents(recordedEvents(recorder), constants.EventReasonDSMUnreachable); got != 1 {
+		t.Errorf("expected 1 event of an unreachable DSM, got %d", got)
+	}
+}
+
+func TestForceDeleteQueuesUserCleanup(t *testing.T) {
+	ctx := context.Background()
+	servers := newTestNAS(t, 2)
+	for _, server := range servers {
+		server.AddUser(testUsername, "password", "users")
+		server.SetError("SYNO.API.Auth", "login", fake.ErrorCodeUnknown)
+	}
+
+	c := fakeclient.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(testSeedObjects(t, testShoot(2))...).Build()
+
+	a, _ := newTestActuator(c, testConfig(servers))
+
+	if err := a.ForceDelete(ctx, logr.Discard(), testExtension()); err != nil {
+		t.Fatalf("failed to force delete: %v", err)
+	}
+
+	entries, err := a.userCleanupQueue.list(ctx, logr.Discard())
+	if err != nil {
+		t.Fatalf("failed to list queued users: %v", err)
+	}
+	if len(entries) != len(servers) {
+		t.Fatalf("expected the user to be queued for %d NAS, got %+v", len(servers), entries)
+	}
+
+	// the admin secrets referenced by the shoot are gone once the shoot is deleted
+	for i := range servers {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s%s-%d", v1beta1constants.ReferencedResourcesPrefix, testAdminSecretPrefix, i), Namespace: testNamespace}}
+		if err := c.Delete(ctx, secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	worker := &userCleanupWorker{log: logr.Discard(), queue: a.userCleanupQueue, clients: a.clients}
+
+	worker.cleanup(ctx)
+	if entries, _ := a.userCleanupQueue.list(ctx, logr.Discard()); len(entries) != len(servers) {
+		t.Fatalf("expected the users to stay queued while the NAS are unreachable, got %+v", entries)
+	}
+
+	for _, server := range servers {
+		server.ClearErrors()
+	}
+	worker.cleanup(ctx)
+
+	for _, server := range servers {
+		if _, ok := server.User(testUsername); ok {
+			t.Errorf("expected user on %s to be deleted", server.URL)
+		}
+	}
+	if entries, _ := a.userCleanupQueue.list(ctx, logr.Discard()); len(entries) != 0 {
+		t.Errorf("expected the queue to be empty, got %+v", entries)
+	}
+	admins := &corev1.SecretList{}
+	if err := c.List(ctx, admins, client.InNamespace(testCleanupNamespace), client.MatchingLabels{userCleanupAdminLabel: "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(admins.Items) != 0 {
+		t.Errorf("expected the admin credentials to be deleted with the last queued user, got %d secrets", len(admins.Items))
+	}
+}
+
+func TestManagedUsersCounter(t *testing.T) {
+	ctx := context.Background()
+	server := newTestNAS(t, 1)[0]
+
+	synologyClient, err := synology.NewClientManager(synology.Limits{}).Get(server.URL, testAdmin, testAdminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := nasClient{nas: nas{url: server.URL}, client: synologyClient}
+
+	counter := newManagedUsersCounter(time.Minute)
+	now := time.Now()
+
+	counter.count(ctx, logr.Discard(), c, now)
+	counter.count(ctx, logr.Discard(), c, now.Add(30*time.Second))
+	if lists := server.Requests("SYNO.Core.User", "list"); lists != 1 {
+		t.Errorf("expected the users to be counted once within the interval, got %d", lists)
+	}
+
+	server.SetErrorTimes("SYNO.Core.User", "list", fake.ErrorCodeUnknown, 1)
+	counter.count(ctx, logr.Discard(), c, now.Add(time.Minute))
+	counter.count(ctx, logr.Discard(), c, now.Add(time.Minute))
+	if lists := server.Requests("SYNO.Core.User", "list"); lists != 3 {
+		t.Errorf("expected a failed count to be retried, got %d lists", lists)
+	}
+}
@@ -2,6 +2,8 @@ package lifecycle
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gardener/gardener/extensions/pkg/controller/extension"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
@@ -11,6 +13,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
+// clientsLogoutTimeout is the time the sessions of the shared DSM clients are logged out within on shutdown
+const clientsLogoutTimeout = 10 * time.Second
+
 // DefaultAddOptions are the default AddOptions for AddToManager
 var DefaultAddOptions = AddOptions{}
 
@@ -24,12 +29,34 @@ type AddOptions struct {
 	ExtensionClass extensionsv1alpha1.ExtensionClass
 	// Config is the extension configuration
 	Config config.ControllerConfiguration
+	// UserCleanupNamespace is the namespace of the queue of DSM users to delete after a forced deletion, it is required
+	UserCleanupNamespace string
 }
 
 // AddToManagerWithOptions adds a controller with the given Options to the given manager
 func AddToManagerWithOptions(ctx context.Context, mgr manager.Manager, opts AddOptions) error {
-	return extension.Add(mgr, extension.AddArgs{
-		Actuator:          NewActuator(mgr.GetClient(), opts.Config),
+	actuator := NewActuator(mgr.GetLogger().WithName("lifecycle"), mgr.GetClient(), mgr.GetEventRecorderFor(constants.ExtensionName), opts.Config)
+
+	if opts.UserCleanupNamespace == "" {
+		return errors.New("no namespace for the user cleanup queue configured")
+	}
+
+	actuator.userCleanupQueue = &userCleanupQueue{
+		client:    mgr.GetClient(),
+		namespace: opts.UserCleanupNamespace,
+	}
+
+	if err := mgr.Add(&userCleanupWorker{
+		log:      mgr.GetLogger().WithName("user-cleanup"),
+		queue:    actuator.userCleanupQueue,
+		clients:  actuator.clients,
+		interval: userCleanupInterval,
+	}); err != nil {
+		return err
+	}
+
+	if err := extension.Add(mgr, extension.AddArgs{
+		Actuator:          actuator,
 		ControllerOptions: opts.ControllerOptions,
 		Name:              constants.ExtensionType,
 		FinalizerSuffix:   constants.ExtensionType,
@@ -39,7 +66,32 @@ func AddToManagerWithOptions(ctx context.Context, mgr manager.Manager, opts AddO
 		ExtensionClasses: []extensionsv1alpha1.ExtensionClass{
 			opts.ExtensionClass,
 		},
-	})
+	}); err != nil {
+		return err
+	}
+
+	if err := addDebugModeController(mgr, actuator, opts.ExtensionClass); err != nil {
+		return err
+	}
+
+	// the sessions of the shared DSM clients are logged out on shutdown
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), clientsLogoutTimeout)
+		defer cancel()
+
+		actuator.clients.Close(ctx)
+		return nil
+	})); err != nil {
+		return err
+	}
+
+	if opts.Config.CapacityRefreshInterval == nil {
+		return nil
+	}
+
+	return addCapacityController(mgr, actuator, opts.Config.CapacityRefreshInterval.Duration, opts.ExtensionClass)
 }
 
 // AddToManager adds a controller with the default Options
@@ -0,0 +1,240 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	extensionsconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	"github.com/gardener/gardener/extensions/pkg/controller"
+	gutil "github.com/gardener/gardener/extensions/pkg/util"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	"github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	predicateutils "github.com/gardener/gardener/pkg/controllerutils/predicate"
+	"github.com/gardener/gardener/pkg/utils/managedresources"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// capacityReconciler periodically publishes the free space of the DSM volumes as CSIStorageCapacity objects and the
+// usage and status of the volumes as ConfigMap in the shoots, both are managed by the capacity managed resource
+type capacityReconciler struct {
+	actuator *Actuator
+	interval time.Duration
+}
+
+// addCapacityController adds the controller publishing the DSM volume capacity to the given manager. Extensions are
+// picked up on start and after each successful reconciliation and then requeued in the given interval.
+func addCapacityController(mgr manager.Manager, actuator *Actuator, interval time.Duration, extensionClass extensionsv1alpha1.ExtensionClass) error {
+	return builder.ControllerManagedBy(mgr).
+		Named(constants.ExtensionType+"-capacity").
+		For(&extensionsv1alpha1.Extension{}, builder.WithPredicates(
+			predicateutils.HasType(constants.ExtensionType),
+			predicateutils.HasClass(extensionClass),
+			predicate.Funcs{
+				CreateFunc: func(event.CreateEvent) bool { return true },
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return predicateutils.ReconciliationFinishedSuccessfully(
+						predicateutils.GetExtensionLastOperation(e.ObjectOld),
+						predicateutils.GetExtensionLastOperation(e.ObjectNew),
+					)
+				},
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			},
+		)).
+		Complete(&capacityReconciler{
+			actuator: actuator,
+			interval: interval,
+		})
+}
+
+// Reconcile publishes the capacity of the DSM volumes in the shoot of the given Extension
+func (r *capacityReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logf.FromContext(ctx)
+
+	ex := &extensionsv1alpha1.Extension{}
+	if err := r.actuator.client.Get(ctx, req.NamespacedName, ex); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if ex.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	// wait for the CSI driver and its storage classes to be deployed
+	if lastOperation := ex.Status.LastOperation; lastOperation == nil || lastOperation.State != gardencorev1beta1.LastOperationStateSucceeded {
+		return reconcile.Result{RequeueAfter: r.interval}, nil
+	}
+
+	cluster, err := controller.GetCluster(ctx, r.actuator.client, ex.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if controller.IsHibernated(cluster) || controller.IsShootFailed(cluster.Shoot) {
+		return reconcile.Result{RequeueAfter: r.interval}, nil
+	}
+
+	volumes := map[string][]synology.Volume{}
+	for _, n := range r.actuator.allNAS() {
+		synologyClient, err := r.actuator.newAdminClient(ctx, cluster, n)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		volumes[n.name], err = synologyClient.ListVolumes(ctx)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to list volumes of Synology NAS %s: %w", n.url, err)
+		}
+	}
+
+	published, missing, err := r.publishCapacity(ctx, ex.Namespace, volumes)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.updateCondition(ctx, ex, missing); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	log.V(1).Info("Published capacity of the DSM volumes", "storageClasses", published, "missing", missing)
+	return reconcile.Result{RequeueAfter: r.interval}, nil
+}
+
+// publishCapacity deploys a CSIStorageCapacity for each storage class of the CSI driver and the ConfigMap with the
+// volume status into the shoot through the capacity managed resource. The volumes are keyed by the name of their NAS.
+// The number of published storage classes and the storage classes without a DSM volume are returned.
+func (r *capacityReconciler) publishCapacity(ctx context.Context, namespace string, volumes map[string][]synology.Volume) (int, []string, error) {
+	storageClassVolumes := map[string][]synology.Volume{}
+	for nas, nasVolumes := range volumes {
+		storageClassVolumes[synology.StorageClassName(nas)] = nasVolumes
+	}
+
+	_, shootClient, err := gutil.NewClientForShoot(ctx, r.actuator.client, namespace, client.Options{}, extensionsconfigv1alpha1.RESTOptions{})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create shoot client: %w", err)
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	if err := shootClient.List(ctx, storageClasses); err != nil {
+		return 0, nil, fmt.Errorf("failed to list storage classes in shoot: %w", err)
+	}
+
+	var (
+		objects []client.Object
+		missing []string
+	)
+	for _, sc := range storageClasses.Items {
+		if sc.Provisioner != constants.CSIDriverName {
+			continue
+		}
+
+		volume, ok := synology.VolumeForStorageClass(&sc, storageClassVolumes[sc.Name])
+		if !ok {
+			missing = append(missing, sc.Name)
+			continue
+		}
+
+		objects = append(objects, synology.GenerateCSIStorageCapacity(constants.ShootTargetNamespace, &sc, volume))
+	}
+	published := len(objects)
+
+	configMap, err := synology.GenerateCapacityConfigMap(constants.ShootTargetNamespace, volumes, time.Now())
+	if err != nil {
+		return 0, nil, err
+	}
+	objects = append(objects, configMap)
+
+	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(objects...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to create registry: %w", err)
+	}
+
+	if err := managedresources.CreateForShoot(ctx, r.actuator.client, namespace, constants.CapacityManagedResourceName, constants.ExtensionType, false, shootResources); err != nil {
+		return 0, nil, fmt.Errorf("unable to create capacity resources: %w", err)
+	}
+
+	return published, missing, nil
+}
+
+// updateCondition reports whether the capacity of every storage class is published. The CSIDriver only enables
+// storage capacity tracking while it is, so pods of storage classes without capacity stay schedulable. The Extension
+// is reconciled on a change to update the CSIDriver.
+func (r *capacityReconciler) updateCondition(ctx context.Context, ex *extensionsv1alpha1.Extension, missing []string) error {
+	var (
+		status  = gardencorev1beta1.ConditionTrue
+		reason  = "CapacityPublished"
+		message = "The capacity of every storage class is published."
+	)
+	if len(missing) > 0 {
+		status = gardencorev1beta1.ConditionFalse
+		reason = "VolumeNotFound"
+		message = fmt.Sprintf("No DSM volume found for the location of the storage classes %s, storage capacity tracking is disabled.", strings.Join(missing, ", "))
+	}
+
+	previous := helper.GetCondition(ex.Status.Conditions, constants.ConditionTypeStorageCapacityPublished)
+	if previous != nil && previous.Status == status && previous.Message == message {
+		return nil
+	}
+	wasPublished := capacityPublished(ex)
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	condition := helper.GetOrInitConditionWithClock(clock.RealClock{}, ex.Status.Conditions, constants.ConditionTypeStorageCapacityPublished)
+	condition = helper.UpdatedConditionWithClock(clock.RealClock{}, condition, status, reason, message)
+	ex.Status.Conditions = helper.MergeConditions(ex.Status.Conditions, condition)
+	if err := r.actuator.client.Status().Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to update extension conditions: %w", err)
+	}
+
+	if wasPublished == capacityPublished(ex) || ex.Annotations[v1beta1constants.GardenerOperation] != "" {
+		return nil
+	}
+
+	patch = client.MergeFrom(ex.DeepCopy())
+	metav1.SetMetaDataAnnotation(&ex.ObjectMeta, v1beta1constants.GardenerOperation, v1beta1constants.GardenerOperationReconcile)
+	if err := r.actuator.client.Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to trigger reconciliation of the extension: %w", err)
+	}
+
+	return nil
+}
+
+// capacityPublished reports whether the capacity of every storage class of the given Extension is published
+func capacityPublished(ex *extensionsv1alpha1.Extension) bool {
+	condition := helper.GetCondition(ex.Status.Conditions, constants.ConditionTypeStorageCapacityPublished)
+	return condition != nil && condition.Status == gardencorev1beta1.ConditionTrue
+}
+
+// deleteCapacity removes the capacity managed resource and with it the published capacity from the shoot
+func (a *Actuator) deleteCapacity(ctx context.Context, ex *extensionsv1alpha1.Extension) error {
+	if err := managedresources.DeleteForShoot(ctx, a.client, ex.Namespace, constants.CapacityManagedResourceName); err != nil {
+		return fmt.Errorf("unable to delete capacity resources: %w", err)
+	}
+
+	if helper.GetCondition(ex.Status.Conditions, constants.ConditionTypeStorageCapacityPublished) == nil {
+		return nil
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Status.Conditions = helper.RemoveConditions(ex.Status.Conditions, constants.ConditionTypeStorageCapacityPublished)
+	if err := a.client.Status().Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to update extension conditions: %w", err)
+	}
+
+	return nil
+}
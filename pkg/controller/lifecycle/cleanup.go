@@ -0,0 +1,385 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gardener/gardener/extensions/pkg/controller"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/gardener/gardener/pkg/utils"
+	"github.com/gardener/gardener/pkg/utils/managedresources"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// userCleanupInterval is the interval the deletion of the queued DSM users is retried in
+const userCleanupInterval = 5 * time.Minute
+
+const (
+	// userCleanupAdminLabel marks the secrets of the queue with the credentials of DSM admins
+	userCleanupAdminLabel = constants.ExtensionType + ".metal-stack.io/user-cleanup-admin"
+	// userCleanupLastQueuedAnnotation is the time a user deleted by the DSM admin of the secret was queued last
+	userCleanupLastQueuedAnnotation = constants.ExtensionType + ".metal-stack.io/last-queued"
+)
+
+// shootResourcesDeletionTimeout is the time Delete waits for the removal of the CSI driver from the shoot
+const shootResourcesDeletionTimeout = 2 * time.Minute
+
+// ForceDelete removes the CSI driver of the shoot from the seed without waiting for the shoot or the NAS, the DSM
+// user of the shoot is queued for deletion once the NAS is reachable
+func (a *Actuator) ForceDelete(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
+	a.queueUserCleanup(ctx, log, ex)
+
+	// the shoot may be gone already, so its objects are left behind instead of waiting for their deletion
+	if err := a.deleteShootResources(ctx, ex.Namespace, true); err != nil {
+		return err
+	}
+
+	if err := a.deleteSeedController(ctx, ex.Namespace); err != nil {
+		return err
+	}
+
+	return a.deleteSeedCredentials(ctx, ex.Namespace)
+}
+
+// deleteShootResources deletes the managed resources of the CSI driver and the published capacity, their objects are
+// kept in the shoot if requested
+func (a *Actuator) deleteShootResources(ctx context.Context, namespace string, keepObjects bool) error {
+	for _, name := range []string{constants.CSIDriverName, constants.CapacityManagedResourceName} {
+		if keepObjects {
+			if err := managedresources.SetKeepObjects(ctx, a.client, namespace, name, true); err != nil {
+				return err
+			}
+		}
+
+		if err := managedresources.DeleteForShoot(ctx, a.client, namespace, name); err != nil {
+			return fmt.Errorf("unable to delete shoot resources %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// waitUntilShootResourcesDeleted waits until the managed resources of the CSI driver and the published capacity are
+// gone, i.e. their objects were removed from the shoot
+func (a *Actuator) waitUntilShootResourcesDeleted(ctx context.Context, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, shootResourcesDeletionTimeout)
+	defer cancel()
+
+	for _, name := range []string{constants.CSIDriverName, constants.CapacityManagedResourceName} {
+		if err := managedresources.WaitUntilDeleted(ctx, a.client, namespace, name); err != nil {
+			return fmt.Errorf("error while waiting for shoot resources %s to be deleted: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUser deletes the DSM user of the shoot on every NAS
+func (a *Actuator) deleteUser(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension, cluster *extensions.Cluster) error {
+	clients, err := a.loginAll(ctx, ex, cluster)
+	if err != nil {
+		return err
+	}
+
+	username := synology.GenerateShootUsername(ex.Namespace, ex.Namespace)
+	for _, c := range clients {
+		if err := c.client.DeleteUser(ctx, username); err != nil {
+			return fmt.Errorf("failed to delete user on Synology NAS %s: %w", c.url, err)
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonUserDeleted, "Deleted DSM user %s on %s", username, c.url)
+		log.Info("Deleted DSM user", "username", username, "url", c.url)
+	}
+
+	return nil
+}
+
+// queueUserCleanup queues the DSM user of the shoot for deletion on every NAS. Failures are only logged, the forced
+// deletion must not be blocked by them.
+func (a *Actuator) queueUserCleanup(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) {
+	username := synology.GenerateShootUsername(ex.Namespace, ex.Namespace)
+	log = log.WithValues("username", username)
+
+	cluster, err := controller.GetCluster(ctx, a.client, ex.Namespace)
+	if err != nil {
+		log.Error(err, "Unable to get cluster, the DSM user is left behind")
+		return
+	}
+
+	for _, n := range a.allNAS() {
+		log := log.WithValues("url", n.url)
+
+		secret, err := a.getAdminSynologySecret(ctx, cluster, n.secretRef)
+		if err != nil {
+			log.Error(err, "Unable to get the admin credentials, the DSM user is left behind")
+			continue
+		}
+
+		adminUsername, adminPassword, err := extractAdminSynologySecret(secret)
+		if err != nil {
+			log.Error(err, "Unable to get the admin credentials, the DSM user is left behind")
+			continue
+		}
+
+		adminSecretName, err := a.userCleanupQueue.addAdmin(ctx, n.url, adminUsername, adminPassword, time.Now())
+		if err != nil {
+			log.Error(err, "Unable to keep the admin credentials, the DSM user is left behind")
+			continue
+		}
+
+		key := username
+		if n.name != "" {
+			key = username + "." + n.name
+		}
+
+		if err := a.userCleanupQueue.add(ctx, key, userCleanup{
+			URL:             n.url,
+			Username:        username,
+			AdminSecretName: adminSecretName,
+			QueuedAt:        metav1.Now(),
+		}); err != nil {
+			log.Error(err, "Unable to queue the DSM user for deletion, the DSM user is left behind")
+			continue
+		}
+
+		log.Info("Queued DSM user for deletion")
+	}
+}
+
+// userCleanup is a DSM user queued for deletion. The admin secret referenced by the shoot is gone by the time the NAS
+// is reachable again, so the admin credentials are kept in a secret of the queue which the entry refers to.
+type userCleanup struct {
+	// URL is the URL of the DSM API the user exists on
+	URL string `json:"url"`
+	// Username is the DSM user to delete
+	Username string `json:"username"`
+	// AdminSecretName is the name of the secret in the namespace of the queue with the credentials of the DSM admin
+	// deleting the user
+	AdminSecretName string `json:"adminSecretName"`
+	// QueuedAt is the time the user was queued
+	QueuedAt metav1.Time `json:"queuedAt"`
+}
+
+// userCleanupQueue keeps the DSM users of force-deleted shoots in a secret in the namespace of the extension, keyed
+// by username and the name of further NAS. The credentials of the DSM admins deleting them are kept in a secret per
+// NAS and admin next to it.
+type userCleanupQueue struct {
+	client    client.Client
+	namespace string
+}
+
+// adminSecret returns the secret of the queue with the credentials of the given DSM admin of the NAS with the given url
+func (q *userCleanupQueue) adminSecret(url, adminUsername string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.UserCleanupSecretName + "-" + utils.ComputeSHA256Hex([]byte(url + "\n" + adminUsername))[:16],
+			Namespace: q.namespace,
+		},
+	}
+}
+
+// addAdmin keeps the given credentials of a DSM admin of the NAS with the given url until the users queued for
+// deletion on the NAS are deleted, the name of their secret is returned
+func (q *userCleanupQueue) addAdmin(ctx context.Context, url, adminUsername, adminPassword string, now time.Time) (string, error) {
+	secret := q.adminSecret(url, adminUsername)
+	if _, err := controllerutil.CreateOrUpdate(ctx, q.client, secret, func() error {
+		metav1.SetMetaDataLabel(&secret.ObjectMeta, userCleanupAdminLabel, "true")
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, userCleanupLastQueuedAnnotation, now.UTC().Format(time.RFC3339))
+		secret.Data = map[string][]byte{
+			constants.SynologySecretAdminUserRef:     []byte(adminUsername),
+			constants.SynologySecretAdminPasswordRef: []byte(adminPassword),
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to keep admin credentials: %w", err)
+	}
+
+	return secret.Name, nil
+}
+
+// adminCredentials returns the DSM admin credentials kept in the secret with the given name
+func (q *userCleanupQueue) adminCredentials(ctx context.Context, name string) (string, string, error) {
+	secret := &corev1.Secret{}
+	if err := q.client.Get(ctx, client.ObjectKey{Namespace: q.namespace, Name: name}, secret); err != nil {
+		return "", "", fmt.Errorf("unable to get admin credentials: %w", err)
+	}
+
+	return extractAdminSynologySecret(secret)
+}
+
+// pruneAdmins deletes the secrets with the credentials of DSM admins no queued user refers to. Admins which were kept
+// within the given interval are retained, their users might not be queued yet.
+func (q *userCleanupQueue) pruneAdmins(ctx context.Context, entries map[string]userCleanup, now time.Time, interval time.Duration) error {
+	secrets := &corev1.SecretList{}
+	if err := q.client.List(ctx, secrets, client.InNamespace(q.namespace), client.MatchingLabels{userCleanupAdminLabel: "true"}); err != nil {
+		return fmt.Errorf("unable to list admin credentials: %w", err)
+	}
+
+	referenced := sets.New[string]()
+	for _, entry := range entries {
+		referenced.Insert(entry.AdminSecretName)
+	}
+
+	var errs error
+	for _, secret := range secrets.Items {
+		if referenced.Has(secret.Name) {
+			continue
+		}
+
+		if lastQueued, err := time.Parse(time.RFC3339, secret.Annotations[userCleanupLastQueuedAnnotation]); err == nil && now.Sub(lastQueued) < interval {
+			continue
+		}
+
+		if err := q.client.Delete(ctx, &secret, client.Preconditions{ResourceVersion: &secret.ResourceVersion}); client.IgnoreNotFound(err) != nil && !apierrors.IsConflict(err) {
+			errs = errors.Join(errs, fmt.Errorf("unable to delete admin credentials %s: %w", secret.Name, err))
+		}
+	}
+
+	return errs
+}
+
+func (q *userCleanupQueue) secret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.UserCleanupSecretName,
+			Namespace: q.namespace,
+		},
+	}
+}
+
+// add queues the given DSM user for deletion under the given key
+func (q *userCleanupQueue) add(ctx context.Context, key string, entry userCleanup) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode user cleanup: %w", err)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := q.secret()
+		_, err := controllerutil.CreateOrUpdate(ctx, q.client, secret, func() error {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = data
+			return nil
+		})
+		return err
+	})
+}
+
+// list returns the queued DSM users by key, entries which cannot be decoded are skipped
+func (q *userCleanupQueue) list(ctx context.Context, log logr.Logger) (map[string]userCleanup, error) {
+	secret := q.secret()
+	if err := q.client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get user cleanup queue: %w", err)
+	}
+
+	entries := map[string]userCleanup{}
+	for key, data := range secret.Data {
+		entry := userCleanup{}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			log.Error(err, "Skipping undecodable user cleanup", "key", key)
+			continue
+		}
+		entries[key] = entry
+	}
+
+	return entries, nil
+}
+
+// remove drops the DSM user with the given key from the queue, the secret is deleted with its last entry
+func (q *userCleanupQueue) remove(ctx context.Context, key string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := q.secret()
+		if err := q.client.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		delete(secret.Data, key)
+		if len(secret.Data) == 0 {
+			return client.IgnoreNotFound(q.client.Delete(ctx, secret, client.Preconditions{ResourceVersion: &secret.ResourceVersion}))
+		}
+
+		return q.client.Update(ctx, secret)
+	})
+}
+
+// userCleanupWorker retries to delete the queued DSM users until their NAS is reachable again
+type userCleanupWorker struct {
+	log      logr.Logger
+	queue    *userCleanupQueue
+	clients  *synology.ClientManager
+	interval time.Duration
+}
+
+// Start implements manager.Runnable, the queue is processed until the given context is cancelled
+func (w *userCleanupWorker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, w.cleanup, w.interval)
+	return nil
+}
+
+// cleanup deletes the queued DSM users, users of unreachable NAS stay queued
+func (w *userCleanupWorker) cleanup(ctx context.Context) {
+	entries, err := w.queue.list(ctx, w.log)
+	if err != nil {
+		w.log.Error(err, "Unable to list the queued DSM users")
+		return
+	}
+
+	for key, entry := range entries {
+		log := w.log.WithValues("username", entry.Username, "url", entry.URL, "queuedAt", entry.QueuedAt)
+
+		if err := w.deleteUser(ctx, entry); err != nil {
+			log.Info("Unable to delete queued DSM user, retrying later", "error", err.Error())
+			continue
+		}
+
+		if err := w.queue.remove(ctx, key); err != nil {
+			log.Error(err, "Unable to remove deleted DSM user from the queue")
+			continue
+		}
+
+		delete(entries, key)
+		log.Info("Deleted queued DSM user")
+	}
+
+	if err := w.queue.pruneAdmins(ctx, entries, time.Now(), w.interval); err != nil {
+		w.log.Error(err, "Unable to delete the admin credentials of the deleted DSM users")
+	}
+}
+
+// deleteUser deletes the DSM user of the given cleanup with its admin credentials
+func (w *userCleanupWorker) deleteUser(ctx context.Context, entry userCleanup) error {
+	adminUsername, adminPassword, err := w.queue.adminCredentials(ctx, entry.AdminSecretName)
+	if err != nil {
+		return err
+	}
+
+	synologyClient, err := w.clients.Get(entry.URL, adminUsername, adminPassword)
+	if err != nil {
+		return fmt.Errorf("failed to create Synology client: %w", err)
+	}
+
+	if err := synologyClient.EnsureLogin(ctx); err != nil {
+		return fmt.Errorf("failed to login to Synology NAS: %w", err)
+	}
+
+	return synologyClient.DeleteUser(ctx, entry.Username)
+}
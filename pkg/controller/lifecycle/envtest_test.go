@@ -0,0 +1,521 @@
+package lifecycle
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/utils/secrets"
+	"github.com/go-logr/logr"
+	csidriversynologyv1alpha1 "github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/v1alpha1"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// moduleDir returns the directory of the given module the extension depends on
+func moduleDir(t *testing.T, module string) string {
+	t.Helper()
+
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", module).Output()
+	if err != nil {
+		t.Fatalf("failed to locate module %s: %v", module, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// testCRDs returns the paths of the CRDs of the Gardener resources the actuator uses and of the custom resources it
+// deploys into the shoot, they are taken from the modules the extension depends on
+func testCRDs(t *testing.T) []string {
+	t.Helper()
+
+	seedCRDs := filepath.Join(moduleDir(t, "github.com/gardener/gardener"), "example", "seed-crds")
+	snapshotCRDs := filepath.Join(moduleDir(t, "github.com/kubernetes-csi/external-snapshotter/client/v4"), "config", "crd")
+	return []string{
+		filepath.Join(seedCRDs, "10-crd-extensions.gardener.cloud_clusters.yaml"),
+		filepath.Join(seedCRDs, "10-crd-extensions.gardener.cloud_extensions.yaml"),
+		filepath.Join(seedCRDs, "10-crd-resources.gardener.cloud_managedresources.yaml"),
+		filepath.Join(seedCRDs, "10-crd-autoscaling.k8s.io_verticalpodautoscalers.yaml"),
+		filepath.Join(snapshotCRDs, "snapshot.storage.k8s.io_volumesnapshotclasses.yaml"),
+	}
+}
+
+// envtestAvailable reports whether the envtest binaries are configured with KUBEBUILDER_ASSETS, they are required
+// in CI
+func envtestAvailable(t *testing.T) bool {
+	t.Helper()
+
+	if os.Getenv("KUBEBUILDER_ASSETS") != "" {
+		return true
+	}
+	if os.Getenv("CI") == "true" {
+		t.Fatal("KUBEBUILDER_ASSETS is not set, the tests against an API server must run in CI")
+	}
+	return false
+}
+
+// startEnvtest starts an API server which serves as seed and, through the shoot kubeconfig in the shoot's namespace,
+// as shoot. The test is skipped if the envtest binaries are not configured with KUBEBUILDER_ASSETS.
+func startEnvtest(t *testing.T) client.Client {
+	t.Helper()
+
+	if !envtestAvailable(t) {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skipping the tests against an API server")
+	}
+
+	testEnv := &envtest.Environment{
+		CRDInstallOptions: envtest.CRDInstallOptions{
+			Paths:              testCRDs(t),
+			ErrorIfPathMissing: true,
+		},
+	}
+
+	restConfig, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("failed to start envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("failed to stop envtest: %v", err)
+		}
+	})
+
+	c, err := client.New(restConfig, client.Options{Scheme: newTestScheme(t)})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	shootUser, err := testEnv.AddUser(envtest.User{Name: "shoot", Groups: []string{"system:masters"}}, nil)
+	if err != nil {
+		t.Fatalf("failed to add shoot user: %v", err)
+	}
+	kubeconfig, err := shootUser.KubeConfig()
+	if err != nil {
+		t.Fatalf("failed to create shoot kubeconfig: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, obj := range []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testCleanupNamespace}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: v1beta1constants.SecretNameGardener, Namespace: testNamespace},
+			Data:       map[string][]byte{secrets.DataKeyKubeconfig: kubeconfig},
+		},
+	} {
+		if err := c.Create(ctx, obj); err != nil {
+			t.Fatalf("failed to create %T %s: %v", obj, obj.GetName(), err)
+		}
+	}
+
+	return c
+}
+
+// decodeManifests decodes the objects of the given multi-document YAML
+func decodeManifests(t *testing.T, codec serializer.CodecFactory, manifests []byte) []client.Object {
+	t.Helper()
+
+	var (
+		objects []client.Object
+		reader  = utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifests)))
+	)
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objects
+		}
+		if err != nil {
+			t.Fatalf("failed to read manifests: %v", err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := codec.UniversalDeserializer().Decode(doc, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to decode manifest: %v", err)
+		}
+		obj.GetObjectKind().SetGroupVersionKind(*gvk)
+
+		o, ok := obj.(client.Object)
+		if !ok {
+			t.Fatalf("manifest of kind %s is no object", gvk)
+		}
+		objects = append(objects, o)
+	}
+}
+
+// shootObjects returns the objects of the ManagedResource of the shoot
+func shootObjects(ctx context.Context, t *testing.T, c client.Client) []client.Object {
+	t.Helper()
+
+	mr := &resourcesv1alpha1.ManagedResource{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: constants.CSIDriverName}, mr); err != nil {
+		t.Fatalf("failed to get managed resource of the shoot: %v", err)
+	}
+
+	var objects []client.Object
+	for _, ref := range mr.Spec.SecretRefs {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: ref.Name}, secret); err != nil {
+			t.Fatalf("failed to get secret of the managed resource: %v", err)
+		}
+
+		manifests, err := decompressManifests(secret.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, decodeManifests(t, kubernetes.ShootCodec, manifests)...)
+	}
+	return objects
+}
+
+// setSuspended sets the suspension of the shoot in the provider config of the given Extension
+func setSuspended(ctx context.Context, t *testing.T, c client.Client, ex *extensionsv1alpha1.Extension, suspended bool) {
+	t.Helper()
+
+	raw, err := json.Marshal(&csidriversynologyv1alpha1.CsiDriverSynologyConfig{
+		TypeMeta:  metav1.TypeMeta{APIVersion: csidriversynologyv1alpha1.SchemeGroupVersion.String(), Kind: "CsiDriverSynologyConfig"},
+		Suspended: suspended,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: raw}
+	if err := c.Patch(ctx, ex, patch); err != nil {
+		t.Fatalf("failed to update provider config: %v", err)
+	}
+}
+
+// shootCredentials returns the secret with the DSM credentials of the shoot among the objects of its ManagedResource
+func shootCredentials(ctx context.Context, t *testing.T, c client.Client) *corev1.Secret {
+	t.Helper()
+
+	for _, obj := range shootObjects(ctx, t, c) {
+		if secret, ok := obj.(*corev1.Secret); ok && secret.Name == constants.SecretName {
+			return secret
+		}
+	}
+
+	t.Fatalf("secret %s not found in the managed resource of the shoot", constants.SecretName)
+	return nil
+}
+
+// clientInfo is the client-info.yaml of the CSI driver
+type clientInfo struct {
+	Clients []clientInfoClient `json:"clients"`
+}
+
+// clientInfoClient is a DSM the CSI driver logs in to
+type clientInfoClient struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	HTTPS    bool   `json:"https"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// seedCredentials returns the DSM credentials of the shoot kept in the seed
+func seedCredentials(ctx context.Context, t *testing.T, c client.Client) (string, string) {
+	t.Helper()
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: constants.SeedCredentialsSecretName}, secret); err != nil {
+		t.Fatalf("failed to get seed credentials: %v", err)
+	}
+
+	username, password, err := extractShootSynologySecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return username, password
+}
+
+// TestActuatorLifecycle runs the actuator against an API server and fake DSMs through the lifecycle of a shoot:
+// creation, suspension, control plane migration and deletion.
+func TestActuatorLifecycle(t *testing.T) {
+	c := startEnvtest(t)
+	ctx := context.Background()
+
+	servers := newTestNAS(t, 2)
+	for _, obj := range testSeedObjects(t, testShoot(len(servers))) {
+		if err := c.Create(ctx, obj); err != nil {
+			t.Fatalf("failed to create %T %s: %v", obj, obj.GetName(), err)
+		}
+	}
+
+	a, recorder := newTestActuator(c, testConfig(servers))
+	log := logr.Discard()
+
+	ex := &extensionsv1alpha1.Extension{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(testExtension()), ex); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Reconcile", func(t *testing.T) {
+		if err := a.Reconcile(ctx, log, ex); err != nil {
+			t.Fatalf("failed to reconcile: %v", err)
+		}
+
+		username, password := seedCredentials(ctx, t, c)
+		if username != testUsername {
+			t.Errorf("expected DSM user %s, got %s", testUsername, username)
+		}
+		for _, server := range servers {
+			if user, ok := server.User(username); !ok || user.Password != password {
+				t.Errorf("expected user with the seed credentials on %s, got %+v", server.URL, user)
+			}
+		}
+		credentials := shootCredentials(ctx, t, c)
+		if credentials.StringData[constants.SynologySecretShootUserRef] != username || credentials.StringData[constants.SynologySecretShootPasswordRef] != password {
+			t.Error("expected the shoot secret to hold the seed credentials")
+		}
+
+		// the CSI driver logs in to every NAS with the credentials of the shoot
+		info := &clientInfo{}
+		if err := utilyaml.Unmarshal([]byte(credentials.StringData["client-info.yaml"]), info); err != nil {
+			t.Fatalf("failed to decode client info: %v", err)
+		}
+		for _, server := range servers {
+			u, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.ContainsFunc(info.Clients, func(client clientInfoClient) bool {
+				return client.Host == u.Hostname() && strconv.Itoa(client.Port) == u.Port() && client.Username == username && client.Password == password
+			}) {
+				t.Errorf("expected a client of %s with the shoot credentials, got %+v", server.URL, info.Clients)
+			}
+		}
+
+		var storageClasses []string
+		for _, obj := range shootObjects(ctx, t, c) {
+			if sc, ok := obj.(*storagev1.StorageClass); ok {
+				storageClasses = append(storageClasses, sc.Name)
+			}
+		}
+		if want := []string{synology.StorageClassName(""), synology.StorageClassName("nas-1")}; !slices.Equal(slices.Sorted(slices.Values(storageClasses)), want) {
+			t.Errorf("expected StorageClasses %v, got %v", want, storageClasses)
+		}
+		if got := countEvents(recordedEvents(recorder), constants.EventReasonUserCreated); got != len(servers) {
+			t.Errorf("expected %d events of created users, got %d", len(servers), got)
+		}
+
+		if err := c.Get(ctx, client.ObjectKeyFromObject(ex), ex); err != nil {
+			t.Fatal(err)
+		}
+		if ex.Status.ProviderStatus == nil {
+			t.Error("expected provider status to be recorded")
+		}
+		if !slices.ContainsFunc(ex.Status.Conditions, func(c gardencorev1beta1.Condition) bool {
+			return c.Type == constants.ConditionTypeSnapshotAPIAvailable && c.Status == gardencorev1beta1.ConditionTrue
+		}) {
+			t.Errorf("expected condition %s to be true, got %+v", constants.ConditionTypeSnapshotAPIAvailable, ex.Status.Conditions)
+		}
+
+		// the existing users and their credentials are kept
+		if err := a.Reconcile(ctx, log, ex); err != nil {
+			t.Fatalf("failed to reconcile again: %v", err)
+		}
+		if _, again := seedCredentials(ctx, t, c); again != password {
+			t.Error("expected the credentials to be reused")
+		}
+		for _, server := range servers {
+			if creates := server.Requests("SYNO.Core.User", "create"); creates != 1 {
+				t.Errorf("expected the user to be created once on %s, got %d", server.URL, creates)
+			}
+		}
+		if got := countEvents(recordedEvents(recorder), constants.EventReasonCredentialsReused); got != 1 {
+			t.Errorf("expected 1 event of reused credentials, got %d", got)
+		}
+	})
+
+	t.Run("Removed worker pool", func(t *testing.T) {
+		raw, err := json.Marshal(&csidriversynologyv1alpha1.CsiDriverSynologyConfig{
+			TypeMeta:   metav1.TypeMeta{APIVersion: csidriversynologyv1alpha1.SchemeGroupVersion.String(), Kind: "CsiDriverSynologyConfig"},
+			NodePlugin: &csidriversynologyv1alpha1.NodePluginConfig{WorkerPools: []string{"removed"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		patch := client.MergeFrom(ex.DeepCopy())
+		ex.Spec.ProviderConfig = &runtime.RawExtension{Raw: raw}
+		if err := c.Patch(ctx, ex, patch); err != nil {
+			t.Fatalf("failed to update provider config: %v", err)
+		}
+
+		if err := a.Reconcile(ctx, log, ex); err != nil {
+			t.Fatalf("expected a worker pool removed from the shoot not to fail the reconciliation: %v", err)
+		}
+		if got := countEvents(recordedEvents(recorder), constants.EventReasonUnknownWorkerPools); got != 1 {
+			t.Errorf("expected 1 event of unknown worker pools, got %d", got)
+		}
+
+		for _, obj := range shootObjects(ctx, t, c) {
+			if ds, ok := obj.(*appsv1.DaemonSet); ok && ds.Spec.Template.Spec.Affinity != nil {
+				t.Errorf("expected the node plugin to be placed on all worker pools, got %+v", ds.Spec.Template.Spec.Affinity)
+			}
+		}
+	})
+
+	t.Run("Suspend and resume", func(t *testing.T) {
+		target := servers[0].AddTarget("target")
+		lun := servers[0].AddLUN("lun", "/volume1", 1<<30, target)
+		otherLUN := servers[0].AddLUN("other-lun", "/volume1", 1<<30, target)
+
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity:    corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: constants.CSIDriverName, VolumeHandle: lun},
+				},
+			},
+		}
+		if err := c.Create(ctx, pv); err != nil {
+			t.Fatalf("failed to create persistent volume: %v", err)
+		}
+
+		setSuspended(ctx, t, c, ex, true)
+		if err := a.Reconcile(ctx, log, ex); err != nil {
+			t.Fatalf("failed to reconcile suspended shoot: %v", err)
+		}
+
+		for _, server := range servers {
+			if user, _ := server.User(testUsername); !user.Expired {
+				t.Errorf("expected user on %s to be disabled", server.URL)
+			}
+		}
+		for _, l := range servers[0].LUNs() {
+			if mapped := len(l.TargetIDs) > 0; mapped != (l.UUID == otherLUN) {
+				t.Errorf("expected only the LUNs of the shoot to be unmapped, LUN %s is mapped=%t", l.Name, mapped)
+			}
+		}
+
+		state, err := loadState(ex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(state.UnmappedTargets[lun], []int{target}) {
+			t.Errorf("expected the unmapped target to be kept in the state, got %+v", state)
+		}
+
+		setSuspended(ctx, t, c, ex, false)
+		if err := a.Reconcile(ctx, log, ex); err != nil {
+			t.Fatalf("failed to reconcile resumed shoot: %v", err)
+		}
+
+		for _, server := range servers {
+			if user, _ := server.User(testUsername); user.Expired {
+				t.Errorf("expected user on %s to be enabled", server.URL)
+			}
+		}
+		for _, l := range servers[0].LUNs() {
+			if !slices.Equal(l.TargetIDs, []int{target}) {
+				t.Errorf("expected LUN %s to be mapped again, got %v", l.Name, l.TargetIDs)
+			}
+		}
+		if state, _ := loadState(ex); len(state.UnmappedTargets) > 0 {
+			t.Errorf("expected the unmapped targets to be removed from the state, got %+v", state)
+		}
+	})
+
+	t.Run("Migrate and restore", func(t *testing.T) {
+		_, password := seedCredentials(ctx, t, c)
+
+		if err := a.Migrate(ctx, log, ex); err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+
+		state := &extensionState{}
+		if err := json.Unmarshal(ex.Status.State.Raw, state); err != nil {
+			t.Fatal(err)
+		}
+		if state.Username != testUsername || state.Endpoint != servers[0].URL {
+			t.Errorf("expected the DSM user to be kept in the state, got %+v", state)
+		}
+
+		mr := &resourcesv1alpha1.ManagedResource{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: constants.CSIDriverName}, mr); !apierrors.IsNotFound(err) {
+			t.Errorf("expected managed resource of the shoot to be deleted, got %v", err)
+		}
+
+		// the new seed starts with a fresh actuator
+		restored, _ := newTestActuator(c, testConfig(servers))
+		if err := restored.Restore(ctx, log, ex); err != nil {
+			t.Fatalf("failed to restore: %v", err)
+		}
+
+		if _, again := seedCredentials(ctx, t, c); again != password {
+			t.Error("expected the restored credentials to be kept")
+		}
+		for _, server := range servers {
+			if user, _ := server.User(testUsername); user.Password != password {
+				t.Errorf("expected the user on %s to keep its password", server.URL)
+			}
+		}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: constants.CSIDriverName}, mr); err != nil {
+			t.Errorf("expected managed resource of the shoot to be recreated: %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := a.Delete(ctx, log, ex); err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+
+		for _, server := range servers {
+			if _, ok := server.User(testUsername); ok {
+				t.Errorf("expected user on %s to be deleted", server.URL)
+			}
+		}
+		if got := countEvents(recordedEvents(recorder), constants.EventReasonUserDeleted); got != len(servers) {
+			t.Errorf("expected %d events of deleted users, got %d", len(servers), got)
+		}
+
+		for _, obj := range []client.Object{
+			&resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Name: constants.CSIDriverName, Namespace: testNamespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SeedCredentialsSecretName, Namespace: testNamespace}},
+		} {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); !apierrors.IsNotFound(err) {
+				t.Errorf("expected %T %s to be deleted, got %v", obj, obj.GetName(), err)
+			}
+		}
+	})
+
+	for _, server := range servers {
+		if names := server.UserNames(); !slices.Equal(names, []string{testAdmin}) {
+			t.Errorf("expected only the admin to be left on %s, got %v", server.URL, names)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// reconcileHibernated reconciles the Extension of a hibernated shoot. The API server of the shoot is down, so the
+// shoot resources are left untouched, the CSI controller in the seed is removed and the DSM user of the shoot is
+// disabled if configured. Both are restored by the first reconciliation after the wake-up.
+func (a *Actuator) reconcileHibernated(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension, cluster *extensions.Cluster) error {
+	log.Info("Shoot is hibernated, skipping the shoot resources")
+
+	if err := a.deleteSeedController(ctx, ex.Namespace); err != nil {
+		return err
+	}
+
+	if !a.config.DisableUserWhileHibernated {
+		return nil
+	}
+
+	username := synology.GenerateShootUsername(ex.Namespace, ex.Namespace)
+
+	for _, n := range a.allNAS() {
+		synologyClient, err := a.newAdminClient(ctx, cluster, n)
+		if err != nil {
+			return err
+		}
+
+		if err := synologyClient.EnsureLogin(ctx); err != nil {
+			a.recorder.Eventf(ex, corev1.EventTypeWarning, constants.EventReasonDSMUnreachable, "Unable to login to the DSM API at %s: %v", n.url, err)
+			return fmt.Errorf("failed to login to Synology NAS %s: %w", n.url, err)
+		}
+
+		user, err := synologyClient.GetUser(ctx, username)
+		if err != nil {
+			return fmt.Errorf("failed to get user from Synology NAS %s: %w", n.url, err)
+		}
+
+		if user == nil || user.Disabled() {
+			continue
+		}
+
+		if err := synologyClient.SetUserDisabled(ctx, username, true); err != nil {
+			return fmt.Errorf("failed to disable user on Synology NAS %s: %w", n.url, err)
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonUserDisabled, "Disabled DSM user %s on %s while the shoot is hibernated", username, n.url)
+		log.Info("Disabled DSM user while the shoot is hibernated", "username", username, "url", n.url)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	predicateutils "github.com/gardener/gardener/pkg/controllerutils/predicate"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// logOutput returns the log level of the CSI driver and the verbosity of the sidecars. While the debug annotation of
+// the shoot lies in the future, the debug log output is used and the end of the debug mode is returned as well.
+func logOutput(log logr.Logger, cfg *csidriversynology.LoggingConfig, shoot *gardencorev1beta1.Shoot, now time.Time) (string, int32, *metav1.Time) {
+	if value, ok := shoot.Annotations[constants.AnnotationDebugUntil]; ok {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Info("Ignoring invalid debug annotation, expected an RFC3339 timestamp", "annotation", constants.AnnotationDebugUntil, "value", value)
+		} else if until.After(now) {
+			return constants.DebugDriverLogLevel, constants.DebugSidecarVerbosity, &metav1.Time{Time: until}
+		}
+	}
+
+	logLevel := constants.DefaultDriverLogLevel
+	verbosity := int32(constants.DefaultSidecarVerbosity)
+	if cfg != nil {
+		if cfg.LogLevel != nil {
+			logLevel = *cfg.LogLevel
+		}
+		if cfg.Verbosity != nil {
+			verbosity = *cfg.Verbosity
+		}
+	}
+
+	return logLevel, verbosity, nil
+}
+
+// debugModeReconciler triggers a reconciliation of Extensions once the debug mode recorded in their provider status
+// expired, so that the log output is reverted without waiting for the next reconciliation of the shoot. The end of
+// the debug mode is kept in the status, so it is not lost on restarts or leader changes of the extension.
+type debugModeReconciler struct {
+	client  client.Client
+	decoder runtime.Decoder
+}
+
+// addDebugModeController adds the controller reverting expired debug modes to the given manager
+func addDebugModeController(mgr manager.Manager, actuator *Actuator, extensionClass extensionsv1alpha1.ExtensionClass) error {
+	return builder.ControllerManagedBy(mgr).
+		Named(constants.ExtensionType+"-debug-mode").
+		For(&extensionsv1alpha1.Extension{}, builder.WithPredicates(
+			predicateutils.HasType(constants.ExtensionType),
+			predicateutils.HasClass(extensionClass),
+			predicate.Funcs{
+				CreateFunc:  func(event.CreateEvent) bool { return true },
+				UpdateFunc:  func(event.UpdateEvent) bool { return true },
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			},
+		)).
+		Complete(&debugModeReconciler{
+			client:  actuator.client,
+			decoder: actuator.decoder,
+		})
+}
+
+// Reconcile requeues the given Extension until its debug mode ends and then triggers its reconciliation
+func (r *debugModeReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logf.FromContext(ctx)
+
+	ex := &extensionsv1alpha1.Extension{}
+	if err := r.client.Get(ctx, req.NamespacedName, ex); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if ex.DeletionTimestamp != nil || ex.Status.ProviderStatus == nil {
+		return reconcile.Result{}, nil
+	}
+
+	status := &csidriversynology.CsiDriverSynologyStatus{}
+	if _, _, err := r.decoder.Decode(ex.Status.ProviderStatus.Raw, nil, status); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to decode provider status: %w", err)
+	}
+
+	if status.DebugUntil == nil {
+		return reconcile.Result{}, nil
+	}
+
+	if remaining := time.Until(status.DebugUntil.Time); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	// a reconciliation after the end of the debug mode reverted the log output already
+	if lastOperation := ex.Status.LastOperation; lastOperation != nil && lastOperation.LastUpdateTime.After(status.DebugUntil.Time) {
+		return reconcile.Result{}, nil
+	}
+
+	if ex.Annotations[v1beta1constants.GardenerOperation] == v1beta1constants.GardenerOperationReconcile {
+		return reconcile.Result{}, nil
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	metav1.SetMetaDataAnnotation(&ex.ObjectMeta, v1beta1constants.GardenerOperation, v1beta1constants.GardenerOperationReconcile)
+	if err := r.client.Patch(ctx, ex, patch); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to trigger reconciliation to revert debug mode: %w", err)
+	}
+
+	log.Info("Debug mode expired, triggered reconciliation")
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,287 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils/managedresources"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// credentialsResourceName is the name of the resource reference to the seed credentials secret in the Extension status
+const credentialsResourceName = "shoot-credentials"
+
+// extensionState is the state of the Extension, Gardener carries it over into the ShootState during control plane
+// migration together with the referenced seed credentials secret
+type extensionState struct {
+	// Username is the DSM user of the shoot
+	Username string `json:"username"`
+	// Endpoint is the URL of the DSM API the user was created on
+	Endpoint string `json:"endpoint"`
+	// UnmappedTargets maps the UUIDs of the LUNs of the shoot to the iSCSI targets they were unmapped from while the
+	// shoot is suspended
+	UnmappedTargets map[string][]int `json:"unmappedTargets,omitempty"`
+	// AdditionalUnmappedTargets are the unmapped iSCSI targets on the further NAS, keyed by the name of the NAS
+	AdditionalUnmappedTargets map[string]map[string][]int `json:"additionalUnmappedTargets,omitempty"`
+}
+
+// unmappedTargets returns the iSCSI targets the LUNs of the shoot on the NAS with the given name were unmapped from,
+// the map is created if it does not exist
+func (s *extensionState) unmappedTargets(nas string) map[string][]int {
+	if nas == "" {
+		if s.UnmappedTargets == nil {
+			s.UnmappedTargets = map[string][]int{}
+		}
+		return s.UnmappedTargets
+	}
+
+	if s.AdditionalUnmappedTargets == nil {
+		s.AdditionalUnmappedTargets = map[string]map[string][]int{}
+	}
+	if s.AdditionalUnmappedTargets[nas] == nil {
+		s.AdditionalUnmappedTargets[nas] = map[string][]int{}
+	}
+	return s.AdditionalUnmappedTargets[nas]
+}
+
+// pruneUnmappedTargets drops the unmapped targets of NAS without any
+func (s *extensionState) pruneUnmappedTargets() {
+	if len(s.UnmappedTargets) == 0 {
+		s.UnmappedTargets = nil
+	}
+	for nas, targets := range s.AdditionalUnmappedTargets {
+		if len(targets) == 0 {
+			delete(s.AdditionalUnmappedTargets, nas)
+		}
+	}
+	if len(s.AdditionalUnmappedTargets) == 0 {
+		s.AdditionalUnmappedTargets = nil
+	}
+}
+
+// loadState decodes the state of the given Extension, an empty state is returned if it has none
+func loadState(ex *extensionsv1alpha1.Extension) (*extensionState, error) {
+	state := &extensionState{}
+	if ex.Status.State == nil || len(ex.Status.State.Raw) == 0 {
+		return state, nil
+	}
+
+	if err := json.Unmarshal(ex.Status.State.Raw, state); err != nil {
+		return nil, fmt.Errorf("failed to decode extension state: %w", err)
+	}
+
+	return state, nil
+}
+
+// saveState records the given state as the state of the given Extension
+func (a *Actuator) saveState(ctx context.Context, ex *extensionsv1alpha1.Extension, state *extensionState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode extension state: %w", err)
+	}
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Status.State = &runtime.RawExtension{Raw: raw}
+	if err := a.client.Status().Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to update extension state: %w", err)
+	}
+
+	return nil
+}
+
+// Migrate persists the DSM user of the shoot into the state of the Extension and removes the CSI driver from the seed
+// while keeping its objects in the shoot
+func (a *Actuator) Migrate(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
+	secret, err := a.getSeedCredentials(ctx, ex.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// the credentials of shoots which were not reconciled since the seed credentials secret was introduced are only
+	// kept in the shoot
+	if secret == nil {
+		secret, err = a.getShootSynologySecret(ctx, ex.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	username, password, err := extractShootSynologySecret(secret)
+	if err != nil {
+		return err
+	}
+
+	if err := a.reconcileSeedCredentials(ctx, ex.Namespace, username, password); err != nil {
+		return err
+	}
+
+	state, err := loadState(ex)
+	if err != nil {
+		return err
+	}
+	state.Username = username
+	state.Endpoint = a.config.SynologyConfig.URL
+
+	patch := client.MergeFrom(ex.DeepCopy())
+	ex.Status.Resources = slices.DeleteFunc(ex.Status.Resources, func(ref gardencorev1beta1.NamedResourceReference) bool {
+		return ref.Name == credentialsResourceName
+	})
+	ex.Status.Resources = append(ex.Status.Resources, gardencorev1beta1.NamedResourceReference{
+		Name: credentialsResourceName,
+		ResourceRef: autoscalingv1.CrossVersionObjectReference{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Name:       constants.SeedCredentialsSecretName,
+		},
+	})
+	if err := a.client.Status().Patch(ctx, ex, patch); err != nil {
+		return fmt.Errorf("failed to update extension resources: %w", err)
+	}
+
+	if err := a.saveState(ctx, ex, state); err != nil {
+		return err
+	}
+
+	log.Info("Persisted DSM user in the extension state", "username", username)
+
+	if err := managedresources.SetKeepObjects(ctx, a.client, ex.Namespace, constants.CSIDriverName, true); err != nil {
+		return err
+	}
+
+	if err := managedresources.DeleteForShoot(ctx, a.client, ex.Namespace, constants.CSIDriverName); err != nil {
+		return fmt.Errorf("unable to delete shoot resources: %w", err)
+	}
+
+	if err := managedresources.SetKeepObjects(ctx, a.client, ex.Namespace, constants.CapacityManagedResourceName, true); err != nil {
+		return err
+	}
+
+	if err := managedresources.DeleteForShoot(ctx, a.client, ex.Namespace, constants.CapacityManagedResourceName); err != nil {
+		return fmt.Errorf("unable to delete capacity resources: %w", err)
+	}
+
+	return a.deleteSeedController(ctx, ex.Namespace)
+}
+
+// Restore rehydrates the DSM user of the shoot from the state of the Extension and reconciles it on the new seed
+func (a *Actuator) Restore(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension) error {
+	state, err := loadState(ex)
+	if err != nil {
+		return err
+	}
+
+	if state.Username == "" {
+		return a.Reconcile(ctx, log, ex)
+	}
+
+	secret, err := a.getSeedCredentials(ctx, ex.Namespace)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return fmt.Errorf("credentials of DSM user %s were not restored into secret %s", state.Username, constants.SeedCredentialsSecretName)
+	}
+
+	username, _, err := extractShootSynologySecret(secret)
+	if err != nil {
+		return err
+	}
+	if username != state.Username {
+		return fmt.Errorf("restored credentials belong to DSM user %s, expected %s", username, state.Username)
+	}
+
+	if state.Endpoint != a.config.SynologyConfig.URL {
+		log.Info("DSM endpoint changed during control plane migration, the user is created if it does not exist on the new endpoint", "previous", state.Endpoint, "endpoint", a.config.SynologyConfig.URL)
+	}
+
+	log.Info("Restored DSM user from the extension state", "username", state.Username)
+
+	return a.Reconcile(ctx, log, ex)
+}
+
+// shootPassword returns the password of the existing DSM user of the shoot, preferring the seed credentials secret
+// over the credentials secret in the shoot
+func (a *Actuator) shootPassword(ctx context.Context, namespace string) (string, error) {
+	secret, err := a.getSeedCredentials(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if secret == nil {
+		secret, err = a.getShootSynologySecret(ctx, namespace)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, password, err := extractShootSynologySecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// getSeedCredentials returns the seed credentials secret of the shoot, nil if it does not exist
+func (a *Actuator) getSeedCredentials(ctx context.Context, namespace string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := a.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: constants.SeedCredentialsSecretName}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get seed credentials secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// reconcileSeedCredentials keeps the credentials of the DSM user of the shoot in the shoot's control plane namespace
+func (a *Actuator) reconcileSeedCredentials(ctx context.Context, namespace, username, password string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.SeedCredentialsSecretName,
+			Namespace: namespace,
+		},
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, a.client, secret, func() error {
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			constants.SynologySecretShootUserRef:     []byte(username),
+			constants.SynologySecretShootPasswordRef: []byte(password),
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to reconcile seed credentials secret: %w", err)
+	}
+
+	return nil
+}
+
+// deleteSeedCredentials removes the seed credentials secret of the shoot
+func (a *Actuator) deleteSeedCredentials(ctx context.Context, namespace string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.SeedCredentialsSecretName,
+			Namespace: namespace,
+		},
+	}
+
+	if err := client.IgnoreNotFound(a.client.Delete(ctx, secret)); err != nil {
+		return fmt.Errorf("unable to delete seed credentials secret: %w", err)
+	}
+
+	return nil
+}
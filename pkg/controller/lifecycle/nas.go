@@ -0,0 +1,55 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nas is a NAS the volumes of the shoots are provisioned on. The NAS of the synology configuration has no name, the
+// further NAS are named by their configuration.
+type nas struct {
+	name      string
+	url       string
+	secretRef string
+}
+
+// allNAS returns the NAS of the synology configuration followed by the further NAS
+func (a *Actuator) allNAS() []nas {
+	all := []nas{{url: a.config.SynologyConfig.URL, secretRef: a.config.SynologyConfig.SecretRef}}
+	for _, n := range a.config.SynologyConfig.AdditionalNAS {
+		all = append(all, nas{name: n.Name, url: n.URL, secretRef: n.SecretRef})
+	}
+	return all
+}
+
+// nasClient is the admin client of a NAS
+type nasClient struct {
+	nas
+	client *synology.Client
+}
+
+// loginAll returns the logged in admin clients of all NAS, a failed login is reported as event of the Extension
+func (a *Actuator) loginAll(ctx context.Context, ex *extensionsv1alpha1.Extension, cluster *extensions.Cluster) ([]nasClient, error) {
+	var clients []nasClient
+	for _, n := range a.allNAS() {
+		synologyClient, err := a.newAdminClient(ctx, cluster, n)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := synologyClient.EnsureLogin(ctx); err != nil {
+			a.recorder.Eventf(ex, corev1.EventTypeWarning, constants.EventReasonDSMUnreachable, "Unable to login to the DSM API at %s: %v", n.url, err)
+			return nil, fmt.Errorf("failed to login to Synology NAS %s: %w", n.url, err)
+		}
+
+		clients = append(clients, nasClient{nas: n, client: synologyClient})
+	}
+
+	return clients, nil
+}
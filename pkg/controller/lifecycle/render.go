@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/gardener/gardener/pkg/utils/managedresources"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology/validation"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/imagevector"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+)
+
+// renderedPassword replaces the password of the DSM user in rendered manifests
+const renderedPassword = "<password>"
+
+// RenderedManagedResource contains the serialized objects of a ManagedResource deployed by the extension
+type RenderedManagedResource struct {
+	// Name is the name of the ManagedResource
+	Name string
+	// Namespace is the namespace of the ManagedResource in the seed
+	Namespace string
+	// Manifests are the objects of the ManagedResource as multi-document YAML
+	Manifests []byte
+}
+
+// Render returns the ManagedResources the extension deploys for the given cluster without accessing any cluster or
+// the NAS. The password of the DSM user is replaced by a placeholder. DSM hostnames are only resolved with the given
+// resolver, without one the DSM ports are opened to any destination for them.
+func Render(ctx context.Context, log logr.Logger, cfg config.ControllerConfiguration, cluster *extensions.Cluster, shootConfig *csidriversynology.CsiDriverSynologyConfig, resolver synology.Resolver) ([]RenderedManagedResource, error) {
+	if errs := validation.ValidateCsiDriverSynologyConfig(shootConfig); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid provider config: %w", errs.ToAggregate())
+	}
+
+	if unknown := dropUnknownWorkerPools(shootConfig, cluster.Shoot.Spec.Provider.Workers); len(unknown) > 0 {
+		log.Info("Ignoring worker pools of the node plugin which do not exist in the shoot", "workerPools", unknown)
+	}
+
+	a := &Actuator{
+		config:      cfg,
+		imageVector: imagevector.WithOverwrites(log, imagevector.ImageVector(), cfg.Images),
+		resolver:    resolver,
+	}
+
+	namespace := cluster.ObjectMeta.Name
+	username := synology.GenerateShootUsername(namespace, namespace)
+	logLevel, verbosity, _ := logOutput(log, shootConfig.Logging, cluster.Shoot, time.Now())
+
+	manifestConfig, err := a.newManifestConfig(ctx, log, cluster, shootConfig, username, renderedPassword, logLevel, verbosity)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := a.generateManifests(manifestConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate resource manifests for shoot: %w", err)
+	}
+
+	shootResources, err := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer).AddAllAndSerialize(objects...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create registry: %w", err)
+	}
+
+	shootManifests, err := decompressManifests(shootResources)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := []RenderedManagedResource{{Name: constants.CSIDriverName, Namespace: namespace, Manifests: shootManifests}}
+
+	if cfg.ControllerInSeed {
+		objects, err := seedControllerObjects(cluster, namespace, manifestConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		seedResources, err := managedresources.NewRegistry(kubernetes.SeedScheme, kubernetes.SeedCodec, kubernetes.SeedSerializer).AddAllAndSerialize(objects...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create registry: %w", err)
+		}
+
+		seedManifests, err := decompressManifests(seedResources)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered = append(rendered, RenderedManagedResource{Name: constants.SeedManagedResourceName, Namespace: namespace, Manifests: seedManifests})
+	}
+
+	return rendered, nil
+}
+
+// decompressManifests returns the objects of the given ManagedResource secret data, which the registry compresses
+func decompressManifests(data map[string][]byte) ([]byte, error) {
+	manifests, err := io.ReadAll(brotli.NewReader(bytes.NewReader(data[resourcesv1alpha1.CompressedDataKey])))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress manifests: %w", err)
+	}
+	return manifests, nil
+}
@@ -0,0 +1,181 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/extensions"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/csidriversynology"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the rendered manifests")
+
+func renderedConfig() config.ControllerConfiguration {
+	return config.ControllerConfiguration{
+		SynologyConfig: config.SynologyConfiguration{
+			URL:       "http://172.18.0.3:5000",
+			SecretRef: "synology-admin",
+		},
+	}
+}
+
+func renderedCluster(workers ...string) *extensions.Cluster {
+	cluster := &extensions.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: testNamespace},
+		Shoot: &gardencorev1beta1.Shoot{
+			Spec: gardencorev1beta1.ShootSpec{
+				Kubernetes: gardencorev1beta1.Kubernetes{Version: "1.33.0"},
+			},
+		},
+	}
+	for _, worker := range workers {
+		cluster.Shoot.Spec.Provider.Workers = append(cluster.Shoot.Spec.Provider.Workers, gardencorev1beta1.Worker{Name: worker})
+	}
+	return cluster
+}
+
+// TestRender compares the rendered manifests with the golden files in testdata, which are rewritten with -update.
+// Every rendered object is created with a server-side dry run against an envtest API server, which runs it through the
+// validation of the API server, and the references between the objects are checked by validateManifests.
+func TestRender(t *testing.T) {
+	var c client.Client
+	if envtestAvailable(t) {
+		c = startEnvtest(t)
+	} else {
+		t.Log("KUBEBUILDER_ASSETS is not set, skipping the validation of the manifests by an API server")
+	}
+
+	controllerInSeed := renderedConfig()
+	controllerInSeed.ControllerInSeed = true
+
+	topology := renderedConfig()
+	topology.SynologyConfig.Topology = &config.SynologyTopology{Key: "topology.kubernetes.io/zone", Values: []string{"zone-a", "zone-b"}}
+
+	multipleNAS := renderedConfig()
+	multipleNAS.SynologyConfig.Topology = &config.SynologyTopology{Key: "topology.kubernetes.io/zone", Values: []string{"zone-a"}}
+	multipleNAS.SynologyConfig.AdditionalNAS = []config.SynologyNAS{{
+		Name:      "nas-b",
+		URL:       "https://172.18.0.4:5001",
+		SecretRef: "synology-admin-b",
+		Location:  "/volume2",
+		Topology:  &config.SynologyTopology{Key: "topology.kubernetes.io/zone", Values: []string{"zone-b"}},
+	}}
+	multipleNAS.AdditionalEgressCIDRs = []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name        string
+		config      config.ControllerConfiguration
+		cluster     *extensions.Cluster
+		shootConfig *csidriversynology.CsiDriverSynologyConfig
+	}{
+		{
+			name:        "default",
+			config:      renderedConfig(),
+			cluster:     renderedCluster(),
+			shootConfig: &csidriversynology.CsiDriverSynologyConfig{},
+		},
+		{
+			name:        "controller-in-seed",
+			config:      controllerInSeed,
+			cluster:     renderedCluster(),
+			shootConfig: &csidriversynology.CsiDriverSynologyConfig{},
+		},
+		{
+			name:        "topology",
+			config:      topology,
+			cluster:     renderedCluster(),
+			shootConfig: &csidriversynology.CsiDriverSynologyConfig{},
+		},
+		{
+			name:    "multiple-nas",
+			config:  multipleNAS,
+			cluster: renderedCluster("storage"),
+			shootConfig: &csidriversynology.CsiDriverSynologyConfig{
+				NodePlugin: &csidriversynology.NodePluginConfig{WorkerPools: []string{"storage"}},
+			},
+		},
+		{
+			name:        "suspended",
+			config:      renderedConfig(),
+			cluster:     renderedCluster(),
+			shootConfig: &csidriversynology.CsiDriverSynologyConfig{Suspended: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := Render(context.Background(), logr.Discard(), tt.config, tt.cluster, tt.shootConfig, nil)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+
+			for _, mr := range rendered {
+				golden := filepath.Join("testdata", tt.name, mr.Name+".yaml")
+
+				if *update {
+					if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(golden, mr.Manifests, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("failed to read golden file, run the test with -update to create it: %v", err)
+				}
+				if !bytes.Equal(want, mr.Manifests) {
+					t.Errorf("rendered manifests of ManagedResource %s differ from %s, run the test with -update if the change is intended", mr.Name, golden)
+				}
+
+				codec := kubernetes.ShootCodec
+				if mr.Name == constants.SeedManagedResourceName {
+					codec = kubernetes.SeedCodec
+				}
+
+				objects := decodeManifests(t, codec, mr.Manifests)
+				if c != nil {
+					dryRunCreate(t, c, objects)
+				}
+
+				if errs := validateManifests(objects); len(errs) > 0 {
+					t.Errorf("invalid manifests of ManagedResource %s: %v", mr.Name, errs.ToAggregate())
+				}
+			}
+		})
+	}
+}
+
+// dryRunCreate creates the objects with a server-side dry run, the API server validates them like on creation
+func dryRunCreate(t *testing.T, c client.Client, objects []client.Object) {
+	t.Helper()
+
+	for _, obj := range objects {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			t.Fatalf("failed to convert %s %s: %v", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+		}
+		u := &unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(gvk)
+
+		if err := c.Create(context.Background(), u, client.DryRunAll); err != nil {
+			t.Errorf("%s %s is invalid: %v", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	extensionsconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	gutil "github.com/gardener/gardener/extensions/pkg/util"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// suspend freezes the access of the shoot to the given NAS. The DSM user of the shoot is disabled and the LUNs of the
+// shoot's persistent volumes are unmapped from their iSCSI targets, which are kept in the state of the Extension.
+func (a *Actuator) suspend(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension, c nasClient, username string, disabled bool) error {
+	synologyClient := c.client
+	log = log.WithValues("url", c.url)
+
+	if !disabled {
+		if err := synologyClient.SetUserDisabled(ctx, username, true); err != nil {
+			return fmt.Errorf("failed to disable user on Synology NAS %s: %w", c.url, err)
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonUserDisabled, "Disabled DSM user %s on %s while the shoot is suspended", username, c.url)
+	}
+
+	luns, err := a.shootLUNs(ctx, ex.Namespace)
+	if err != nil {
+		return err
+	}
+
+	targets, err := synologyClient.ListTargets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list iSCSI targets: %w", err)
+	}
+
+	state, err := loadState(ex)
+	if err != nil {
+		return err
+	}
+	unmappedTargets := state.unmappedTargets(c.name)
+
+	var (
+		unmapErr error
+		unmapped int
+	)
+	for _, target := range targets {
+		for _, lun := range target.MappedLUNs {
+			if !luns.Has(lun) {
+				continue
+			}
+
+			if err := synologyClient.UnmapTarget(ctx, lun, []int{target.TargetID}); err != nil {
+				unmapErr = errors.Join(unmapErr, fmt.Errorf("failed to unmap LUN %s from iSCSI target %s: %w", lun, target.Name, err))
+				continue
+			}
+
+			unmappedTargets[lun] = append(unmappedTargets[lun], target.TargetID)
+			unmapped++
+		}
+	}
+
+	if unmapped == 0 {
+		return unmapErr
+	}
+
+	if err := a.saveState(ctx, ex, state); err != nil {
+		return errors.Join(unmapErr, err)
+	}
+
+	a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonSuspended, "Unmapped %d LUNs of the shoot from their iSCSI targets on %s", unmapped, c.url)
+	log.Info("Suspended the shoot's access to the NAS", "unmapped", unmapped)
+
+	return unmapErr
+}
+
+// resume restores the access of the shoot to the given NAS after a suspension. The DSM user of the shoot is enabled
+// and the LUNs kept in the state of the Extension are mapped to their iSCSI targets again.
+func (a *Actuator) resume(ctx context.Context, log logr.Logger, ex *extensionsv1alpha1.Extension, c nasClient, username string, disabled bool) error {
+	synologyClient := c.client
+	log = log.WithValues("url", c.url)
+
+	if disabled {
+		if err := synologyClient.SetUserDisabled(ctx, username, false); err != nil {
+			return fmt.Errorf("failed to enable user on Synology NAS %s: %w", c.url, err)
+		}
+
+		a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonUserEnabled, "Enabled DSM user %s on %s", username, c.url)
+	}
+
+	state, err := loadState(ex)
+	if err != nil {
+		return err
+	}
+
+	unmappedTargets := state.unmappedTargets(c.name)
+	if len(unmappedTargets) == 0 {
+		return nil
+	}
+
+	var (
+		mapErr error
+		mapped int
+	)
+	for lun, targetIDs := range unmappedTargets {
+		if err := synologyClient.MapTarget(ctx, lun, targetIDs); err != nil {
+			mapErr = errors.Join(mapErr, fmt.Errorf("failed to map LUN %s to its iSCSI targets: %w", lun, err))
+			continue
+		}
+
+		delete(unmappedTargets, lun)
+		mapped++
+	}
+
+	if mapped == 0 {
+		return mapErr
+	}
+
+	state.pruneUnmappedTargets()
+
+	if err := a.saveState(ctx, ex, state); err != nil {
+		return errors.Join(mapErr, err)
+	}
+
+	a.recorder.Eventf(ex, corev1.EventTypeNormal, constants.EventReasonResumed, "Mapped %d LUNs of the shoot to their iSCSI targets on %s again", mapped, c.url)
+	log.Info("Resumed the shoot's access to the NAS", "mapped", mapped)
+
+	return mapErr
+}
+
+// shootLUNs returns the UUIDs of the LUNs backing the persistent volumes of the CSI driver in the shoot
+func (a *Actuator) shootLUNs(ctx context.Context, namespace string) (sets.Set[string], error) {
+	_, shootClient, err := gutil.NewClientForShoot(ctx, a.client, namespace, client.Options{}, extensionsconfigv1alpha1.RESTOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shoot client: %w", err)
+	}
+
+	pvs := &corev1.PersistentVolumeList{}
+	if err := shootClient.List(ctx, pvs); err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes of the shoot: %w", err)
+	}
+
+	luns := sets.New[string]()
+	for _, pv := range pvs.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == constants.CSIDriverName {
+			luns.Insert(pv.Spec.CSI.VolumeHandle)
+		}
+	}
+
+	return luns, nil
+}
@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+)
+
+// managedUsersInterval is the interval the shoot users on a NAS are counted in
+const managedUsersInterval = 10 * time.Minute
+
+// managedUsersCounter records the number of shoot users on each NAS. Counting lists all users of the NAS, so it is
+// done at most once per interval and NAS instead of on every reconciliation.
+type managedUsersCounter struct {
+	interval time.Duration
+
+	lock    sync.Mutex
+	counted map[string]time.Time
+}
+
+func newManagedUsersCounter(interval time.Duration) *managedUsersCounter {
+	return &managedUsersCounter{
+		interval: interval,
+		counted:  map[string]time.Time{},
+	}
+}
+
+// count records the number of shoot users on the given NAS if it was not counted within the interval
+func (m *managedUsersCounter) count(ctx context.Context, log logr.Logger, c nasClient, now time.Time) {
+	if !m.claim(c.url, now) {
+		return
+	}
+
+	u, err := url.Parse(c.url)
+	if err != nil {
+		log.Error(err, "Unable to parse the url of the Synology NAS", "url", c.url)
+		return
+	}
+
+	count, err := c.client.CountShootUsers(ctx)
+	if err != nil {
+		m.release(c.url)
+		log.Error(err, "Unable to count the shoot users on the Synology NAS", "url", c.url)
+		return
+	}
+
+	synology.SetManagedUsers(u.Hostname(), count)
+}
+
+// claim reports whether the NAS with the given url is due to be counted, it is not due again within the interval
+func (m *managedUsersCounter) claim(url string, now time.Time) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if last, ok := m.counted[url]; ok && now.Sub(last) < m.interval {
+		return false
+	}
+
+	m.counted[url] = now
+	return true
+}
+
+// release makes the NAS with the given url due again after a failed count
+func (m *managedUsersCounter) release(url string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.counted, url)
+}
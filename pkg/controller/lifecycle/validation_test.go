@@ -0,0 +1,159 @@
+package lifecycle
+
+import (
+	"reflect"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// podTemplate is the pod template of a generated workload
+type podTemplate struct {
+	namespace string
+	template  *corev1.PodTemplateSpec
+	fldPath   *field.Path
+}
+
+// validateManifests checks the references between the generated objects, which the API server does not validate,
+// e.g. selectors matching no pods, service ports targeting undefined container ports, bindings of undefined cluster
+// roles or volumes of undefined secrets.
+func validateManifests(objects []client.Object) field.ErrorList {
+	var (
+		allErrs   field.ErrorList
+		templates []podTemplate
+	)
+
+	for _, obj := range objects {
+		var template *corev1.PodTemplateSpec
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			template = &o.Spec.Template
+		case *appsv1.DaemonSet:
+			template = &o.Spec.Template
+		case *appsv1.StatefulSet:
+			template = &o.Spec.Template
+		}
+
+		if template != nil {
+			templates = append(templates, podTemplate{
+				namespace: obj.GetNamespace(),
+				template:  template,
+				fldPath:   objectPath(obj).Child("spec", "template", "spec"),
+			})
+		}
+	}
+
+	for _, t := range templates {
+		allErrs = append(allErrs, validatePodReferences(t, objects)...)
+	}
+
+	for _, obj := range objects {
+		fldPath := objectPath(obj)
+		switch o := obj.(type) {
+		case *corev1.Service:
+			allErrs = append(allErrs, validateServiceTargetPorts(o, templates, fldPath.Child("spec"))...)
+		case *policyv1.PodDisruptionBudget:
+			if o.Spec.Selector != nil && len(selectedTemplates(o.Namespace, o.Spec.Selector.MatchLabels, templates)) == 0 {
+				allErrs = append(allErrs, field.NotFound(fldPath.Child("spec", "selector"), o.Spec.Selector.MatchLabels))
+			}
+		case *rbacv1.ClusterRoleBinding:
+			if !containsObject(objects, &rbacv1.ClusterRole{}, "", o.RoleRef.Name) {
+				allErrs = append(allErrs, field.NotFound(fldPath.Child("roleRef", "name"), o.RoleRef.Name))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// objectPath returns the root path of errors of the given object, e.g. Deployment[kube-system/name]
+func objectPath(obj client.Object) *field.Path {
+	return field.NewPath(reflect.TypeOf(obj).Elem().Name()).Key(client.ObjectKeyFromObject(obj).String())
+}
+
+// containsObject returns whether an object of the kind of the given one with the given namespace and name is among
+// the objects
+func containsObject(objects []client.Object, kind client.Object, namespace, name string) bool {
+	return slices.ContainsFunc(objects, func(obj client.Object) bool {
+		return reflect.TypeOf(obj) == reflect.TypeOf(kind) && obj.GetNamespace() == namespace && obj.GetName() == name
+	})
+}
+
+// selectedTemplates returns the pod templates in the given namespace which match the given selector
+func selectedTemplates(namespace string, selector map[string]string, templates []podTemplate) []podTemplate {
+	var selected []podTemplate
+	for _, t := range templates {
+		if t.namespace == namespace && labels.SelectorFromSet(selector).Matches(labels.Set(t.template.Labels)) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// validatePodReferences checks that the service account and the secret and config map volumes of the pod template are
+// among the objects, unless the volumes are optional
+func validatePodReferences(t podTemplate, objects []client.Object) field.ErrorList {
+	var (
+		allErrs field.ErrorList
+		spec    = t.template.Spec
+	)
+
+	if name := spec.ServiceAccountName; name != "" && !containsObject(objects, &corev1.ServiceAccount{}, t.namespace, name) {
+		allErrs = append(allErrs, field.NotFound(t.fldPath.Child("serviceAccountName"), name))
+	}
+
+	for i, volume := range spec.Volumes {
+		idxPath := t.fldPath.Child("volumes").Index(i)
+		switch {
+		case volume.Secret != nil && !ptr.Deref(volume.Secret.Optional, false):
+			if !containsObject(objects, &corev1.Secret{}, t.namespace, volume.Secret.SecretName) {
+				allErrs = append(allErrs, field.NotFound(idxPath.Child("secret", "secretName"), volume.Secret.SecretName))
+			}
+		case volume.ConfigMap != nil && !ptr.Deref(volume.ConfigMap.Optional, false):
+			if !containsObject(objects, &corev1.ConfigMap{}, t.namespace, volume.ConfigMap.Name) {
+				allErrs = append(allErrs, field.NotFound(idxPath.Child("configMap", "name"), volume.ConfigMap.Name))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateServiceTargetPorts checks that the service selects pods and that its named target ports are container ports
+// of the selected pods
+func validateServiceTargetPorts(service *corev1.Service, templates []podTemplate, fldPath *field.Path) field.ErrorList {
+	if len(service.Spec.Selector) == 0 {
+		return nil
+	}
+
+	selected := selectedTemplates(service.Namespace, service.Spec.Selector, templates)
+	if len(selected) == 0 {
+		return field.ErrorList{field.NotFound(fldPath.Child("selector"), service.Spec.Selector)}
+	}
+
+	var portNames []string
+	for _, t := range selected {
+		for _, container := range t.template.Spec.Containers {
+			for _, port := range container.Ports {
+				portNames = append(portNames, port.Name)
+			}
+		}
+	}
+
+	var allErrs field.ErrorList
+	for i, port := range service.Spec.Ports {
+		if port.TargetPort.Type == intstr.String && !slices.Contains(portNames, port.TargetPort.StrVal) {
+			allErrs = append(allErrs, field.NotFound(fldPath.Child("ports").Index(i).Child("targetPort"), port.TargetPort.StrVal))
+		}
+	}
+
+	return allErrs
+}
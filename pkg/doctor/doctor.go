@@ -0,0 +1,237 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// MinimumDSMMajorVersion is the oldest major DSM version supported by the Synology CSI driver
+const MinimumDSMMajorVersion = 7
+
+// RequiredAPIs are the DSM APIs used by the extension and the Synology CSI driver
+var RequiredAPIs = []string{
+	"SYNO.API.Auth",
+	"SYNO.API.Info",
+	"SYNO.DSM.Info",
+	"SYNO.Core.User",
+	"SYNO.Core.ISCSI.LUN",
+	"SYNO.Core.ISCSI.Target",
+	"SYNO.Storage.CGI.Storage",
+}
+
+var dsmVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// Options configure the preflight checks
+type Options struct {
+	// NAS is the name of a further NAS of the configuration, the NAS of the synology configuration has no name
+	NAS string
+	// URL is the URL of the DSM API
+	URL string
+	// Username is the name of the DSM admin user
+	Username string
+	// Password is the password of the DSM admin user
+	Password string
+	// Volume is the path of the DSM volume the LUNs are created on
+	Volume string
+	// MinFreeSpace is the free space the volume needs at least
+	MinFreeSpace resource.Quantity
+	// DialTimeout is the timeout of the connection to the iSCSI portal
+	DialTimeout time.Duration
+}
+
+// Check is the result of a single preflight check
+type Check struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// Report contains the results of the preflight checks of a NAS
+type Report struct {
+	NAS    string  `json:"nas,omitempty"`
+	URL    string  `json:"url"`
+	Passed bool    `json:"passed"`
+	Checks []Check `json:"checks"`
+}
+
+func (r *Report) add(name string, passed bool, format string, args ...any) {
+	r.Checks = append(r.Checks, Check{Name: name, Passed: passed, Message: fmt.Sprintf(format, args...)})
+	r.Passed = r.Passed && passed
+}
+
+// Run checks whether the NAS behind the given URL meets the requirements of the extension. Checks depending on a
+// successful login are skipped if the login fails.
+func Run(ctx context.Context, opts Options) *Report {
+	report := &Report{NAS: opts.NAS, URL: opts.URL, Passed: true}
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		report.add("url", false, "invalid DSM URL: %v", err)
+		return report
+	}
+
+	c, err := synology.NewClient(opts.URL, opts.Username, opts.Password)
+	if err != nil {
+		report.add("url", false, "%v", err)
+		return report
+	}
+
+	if err := c.Login(ctx); err != nil {
+		report.add("login", false, "unable to login as %s: %v", opts.Username, err)
+		return report
+	}
+	defer c.Logout(ctx)
+	report.add("login", true, "logged in as %s", opts.Username)
+
+	checkDSMVersion(ctx, report, c)
+	checkAPIs(ctx, report, c)
+	checkAdminPrivileges(ctx, report, c)
+	checkISCSIService(ctx, report, u.Hostname(), opts.DialTimeout)
+	checkVolume(ctx, report, c, opts.Volume, opts.MinFreeSpace)
+
+	return report
+}
+
+func checkDSMVersion(ctx context.Context, report *Report, c *synology.Client) {
+	version, err := c.DSMVersion(ctx)
+	if err != nil {
+		report.add("dsm-version", false, "unable to get the DSM version: %v", err)
+		return
+	}
+
+	match := dsmVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		report.add("dsm-version", false, "unable to parse DSM version %q", version)
+		return
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	if major < MinimumDSMMajorVersion {
+		report.add("dsm-version", false, "%s is not supported, at least DSM %d.0 is required", version, MinimumDSMMajorVersion)
+		return
+	}
+
+	report.add("dsm-version", true, "%s", version)
+}
+
+func checkAPIs(ctx context.Context, report *Report, c *synology.Client) {
+	apis, err := c.QueryAPIs(ctx)
+	if err != nil {
+		report.add("apis", false, "unable to query the DSM APIs: %v", err)
+		return
+	}
+
+	var missing []string
+	for _, api := range RequiredAPIs {
+		if _, ok := apis[api]; !ok {
+			missing = append(missing, api)
+		}
+	}
+
+	if len(missing) > 0 {
+		report.add("apis", false, "missing DSM APIs: %s", strings.Join(missing, ", "))
+		return
+	}
+
+	report.add("apis", true, "all %d required DSM APIs are available", len(RequiredAPIs))
+}
+
+// checkAdminPrivileges lists the users of the NAS, which DSM only permits to administrators
+func checkAdminPrivileges(ctx context.Context, report *Report, c *synology.Client) {
+	if _, err := c.ListUsers(ctx); err != nil {
+		report.add("admin-privileges", false, "the user is not permitted to manage DSM users, it needs to be a member of the administrators group: %v", err)
+		return
+	}
+
+	report.add("admin-privileges", true, "the user is permitted to manage DSM users")
+}
+
+// checkISCSIService connects to the iSCSI portal of the NAS, which only accepts connections if the iSCSI service runs
+func checkISCSIService(ctx context.Context, report *Report, host string, timeout time.Duration) {
+	address := net.JoinHostPort(host, strconv.Itoa(constants.ISCSIPort))
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		report.add("iscsi-service", false, "the iSCSI portal %s is not reachable, the iSCSI service needs to be enabled: %v", address, err)
+		return
+	}
+	_ = conn.Close()
+
+	report.add("iscsi-service", true, "the iSCSI portal %s is reachable", address)
+}
+
+func checkVolume(ctx context.Context, report *Report, c *synology.Client, volumePath string, minFreeSpace resource.Quantity) {
+	volumes, err := c.ListVolumes(ctx)
+	if err != nil {
+		report.add("target-volume", false, "unable to list the DSM volumes: %v", err)
+		return
+	}
+
+	idx := slices.IndexFunc(volumes, func(v synology.Volume) bool {
+		return path.Clean(v.Path) == path.Clean(volumePath)
+	})
+	if idx < 0 {
+		paths := make([]string, 0, len(volumes))
+		for _, v := range volumes {
+			paths = append(paths, v.Path)
+		}
+		report.add("target-volume", false, "volume %s does not exist, available volumes: %s", volumePath, strings.Join(paths, ", "))
+		return
+	}
+
+	volume := volumes[idx]
+	if volume.Status != "normal" {
+		report.add("target-volume", false, "volume %s is %s", volume.Path, volume.Status)
+	} else {
+		report.add("target-volume", true, "volume %s is %s", volume.Path, volume.Status)
+	}
+
+	free := resource.NewQuantity(volume.FreeBytes(), resource.BinarySI)
+	if free.Cmp(minFreeSpace) < 0 {
+		report.add("free-space", false, "volume %s has %s free, at least %s are required", volume.Path, free, &minFreeSpace)
+		return
+	}
+
+	report.add("free-space", true, "volume %s has %s free", volume.Path, free)
+}
+
+// WriteText writes the report in a human readable form
+func WriteText(w io.Writer, report *Report) error {
+	var b strings.Builder
+
+	if report.NAS != "" {
+		fmt.Fprintf(&b, "Preflight checks for NAS %s at %s\n", report.NAS, report.URL)
+	} else {
+		fmt.Fprintf(&b, "Preflight checks for %s\n", report.URL)
+	}
+	for _, check := range report.Checks {
+		result := "PASS"
+		if !check.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(&b, "[%s] %-16s %s\n", result, check.Name, check.Message)
+	}
+
+	if report.Passed {
+		b.WriteString("All checks passed.\n")
+	} else {
+		b.WriteString("Some checks failed.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
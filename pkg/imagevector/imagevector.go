@@ -0,0 +1,122 @@
+package imagevector
+
+import (
+	"fmt"
+
+	"github.com/gardener/gardener/pkg/utils/imagevector"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/charts"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+)
+
+var imageVector imagevector.ImageVector
+
+func init() {
+	var err error
+
+	imageVector, err = imagevector.Read([]byte(charts.ImagesYAML))
+	runtime.Must(err)
+
+	imageVector, err = imagevector.WithEnvOverride(imageVector, imagevector.OverrideEnv)
+	runtime.Must(err)
+}
+
+// ImageVector is the image vector that contains all the needed images.
+func ImageVector() imagevector.ImageVector {
+	return imageVector
+}
+
+// WithOverwrites applies the image overwrites of the controller configuration to the entries of the given image
+// vector with the same name. A repository or tag missing in an overwrite is kept from the overwritten entry, so the
+// entries keep their target versions. Overwrites which do not resolve to a full image are rejected by
+// ValidateOverwrite and skipped here with an error log.
+func WithOverwrites(log logr.Logger, vector imagevector.ImageVector, overwrites []config.ImageOverwrite) imagevector.ImageVector {
+	if len(overwrites) == 0 {
+		return vector
+	}
+
+	out := make(imagevector.ImageVector, 0, len(vector))
+	for _, source := range vector {
+		for _, o := range overwrites {
+			if o.Name != source.Name {
+				continue
+			}
+			image, err := overwrite(source, o)
+			if err != nil {
+				log.Error(err, "Skipping image overwrite", "image", o.Name)
+				continue
+			}
+			source = image
+		}
+		out = append(out, source)
+	}
+
+	return out
+}
+
+// ValidateOverwrite returns an error if the given overwrite does not resolve to a full image for every entry of the
+// given image vector it applies to
+func ValidateOverwrite(vector imagevector.ImageVector, o config.ImageOverwrite) error {
+	found := false
+	for _, source := range vector {
+		if source.Name != o.Name {
+			continue
+		}
+		found = true
+
+		if _, err := overwrite(source, o); err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("image %s is not part of the image vector", o.Name)
+	}
+
+	return nil
+}
+
+// overwrite returns a copy of the given image source with the repository and tag of the given overwrite
+func overwrite(source *imagevector.ImageSource, o config.ImageOverwrite) (*imagevector.ImageSource, error) {
+	image := *source
+
+	if image.Ref != nil {
+		// a reference cannot be split into repository and tag, so both must be overwritten
+		if o.Repository == nil || o.Tag == nil {
+			return nil, fmt.Errorf("image %s is referenced as %s in the image vector, the overwrite must set both repository and tag", o.Name, *image.Ref)
+		}
+		image.Ref = nil
+	}
+
+	if o.Repository != nil {
+		image.Repository = o.Repository
+	}
+	if o.Tag != nil {
+		image.Tag = o.Tag
+	}
+
+	if image.Repository == nil || image.Tag == nil {
+		return nil, fmt.Errorf("image %s has no repository or tag in the image vector, the overwrite must set both", o.Name)
+	}
+
+	return &image, nil
+}
+
+// FindImages returns the images of all components deployed for a shoot with the given Kubernetes version, keyed by
+// their image vector name.
+func FindImages(vector imagevector.ImageVector, shootVersion string) (map[string]string, error) {
+	images, err := imagevector.FindImages(vector, constants.ImageNames, imagevector.TargetVersion(shootVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(images))
+	for name, image := range images {
+		out[name] = image.String()
+	}
+
+	return out, nil
+}
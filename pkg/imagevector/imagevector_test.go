@@ -0,0 +1,182 @@
+package imagevector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gardener/gardener/pkg/utils/imagevector"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/ptr"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/apis/config"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+)
+
+// testVector returns the embedded image vector with the CSI driver image split into entries for older and newer
+// shoots and the provisioner referenced as full image
+func testVector() imagevector.ImageVector {
+	var vector imagevector.ImageVector
+	for _, source := range ImageVector() {
+		switch source.Name {
+		case constants.ImageNameCSIDriver:
+			vector = append(vector,
+				&imagevector.ImageSource{Name: source.Name, Repository: ptr.To("synology/synology-csi"), Tag: ptr.To("v1.1.0"), TargetVersion: ptr.To("< 1.30")},
+				&imagevector.ImageSource{Name: source.Name, Repository: ptr.To("synology/synology-csi"), Tag: ptr.To("v1.1.2"), TargetVersion: ptr.To(">= 1.30")},
+			)
+		case constants.ImageNameCSIProvisioner:
+			vector = append(vector, &imagevector.ImageSource{Name: source.Name, Ref: ptr.To("registry.k8s.io/sig-storage/csi-provisioner:v5.1.0")})
+		default:
+			vector = append(vector, source)
+		}
+	}
+	return vector
+}
+
+func TestValidateOverwrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		overwrite config.ImageOverwrite
+		wantErr   string
+	}{
+		{
+			name:      "repository only",
+			overwrite: config.ImageOverwrite{Name: constants.ImageNameCSIDriver, Repository: ptr.To("mirror.example.com/synology-csi")},
+		},
+		{
+			name:      "tag only",
+			overwrite: config.ImageOverwrite{Name: constants.ImageNameCSIDriver, Tag: ptr.To("v1.2.0")},
+		},
+		{
+			name:      "ref entry with repository and tag",
+			overwrite: config.ImageOverwrite{Name: constants.ImageNameCSIProvisioner, Repository: ptr.To("mirror.example.com/csi-provisioner"), Tag: ptr.To("v5.2.0")},
+		},
+		{
+			name:      "ref entry with repository only",
+			overwrite: config.ImageOverwrite{Name: constants.ImageNameCSIProvisioner, Repository: ptr.To("mirror.example.com/csi-provisioner")},
+			wantErr:   "must set both repository and tag",
+		},
+		{
+			name:      "ref entry with tag only",
+			overwrite: config.ImageOverwrite{Name: constants.ImageNameCSIProvisioner, Tag: ptr.To("v5.2.0")},
+			wantErr:   "must set both repository and tag",
+		},
+		{
+			name:      "unknown image",
+			overwrite: config.ImageOverwrite{Name: "unknown", Tag: ptr.To("v1.0.0")},
+			wantErr:   "not part of the image vector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOverwrite(testVector(), tt.overwrite)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithOverwrites(t *testing.T) {
+	tests := []struct {
+		name         string
+		overwrites   []config.ImageOverwrite
+		shootVersion string
+		want         map[string]string
+	}{
+		{
+			name:         "no overwrites on older shoot",
+			shootVersion: "1.29.5",
+			want: map[string]string{
+				constants.ImageNameCSIDriver:      "synology/synology-csi:v1.1.0",
+				constants.ImageNameCSIProvisioner: "registry.k8s.io/sig-storage/csi-provisioner:v5.1.0",
+			},
+		},
+		{
+			name:         "no overwrites on newer shoot",
+			shootVersion: "1.31.2",
+			want: map[string]string{
+				constants.ImageNameCSIDriver: "synology/synology-csi:v1.1.2",
+			},
+		},
+		{
+			name:         "repository overwrite keeps the tag of each target version",
+			overwrites:   []config.ImageOverwrite{{Name: constants.ImageNameCSIDriver, Repository: ptr.To("mirror.example.com/synology-csi")}},
+			shootVersion: "1.29.5",
+			want: map[string]string{
+				constants.ImageNameCSIDriver: "mirror.example.com/synology-csi:v1.1.0",
+			},
+		},
+		{
+			name:         "repository overwrite applies to every target version",
+			overwrites:   []config.ImageOverwrite{{Name: constants.ImageNameCSIDriver, Repository: ptr.To("mirror.example.com/synology-csi")}},
+			shootVersion: "1.31.2",
+			want: map[string]string{
+				constants.ImageNameCSIDriver: "mirror.example.com/synology-csi:v1.1.2",
+			},
+		},
+		{
+			name:         "tag overwrite keeps the repository",
+			overwrites:   []config.ImageOverwrite{{Name: constants.ImageNameCSIDriver, Tag: ptr.To("v1.2.0")}},
+			shootVersion: "1.29.5",
+			want: map[string]string{
+				constants.ImageNameCSIDriver: "synology/synology-csi:v1.2.0",
+			},
+		},
+		{
+			name:         "ref entry with repository and tag",
+			overwrites:   []config.ImageOverwrite{{Name: constants.ImageNameCSIProvisioner, Repository: ptr.To("mirror.example.com/csi-provisioner"), Tag: ptr.To("v5.2.0")}},
+			shootVersion: "1.31.2",
+			want: map[string]string{
+				constants.ImageNameCSIProvisioner: "mirror.example.com/csi-provisioner:v5.2.0",
+			},
+		},
+		{
+			name:         "invalid overwrite of a ref entry is skipped",
+			overwrites:   []config.ImageOverwrite{{Name: constants.ImageNameCSIProvisioner, Tag: ptr.To("v5.2.0")}},
+			shootVersion: "1.31.2",
+			want: map[string]string{
+				constants.ImageNameCSIProvisioner: "registry.k8s.io/sig-storage/csi-provisioner:v5.1.0",
+			},
+		},
+		{
+			name: "later overwrites win",
+			overwrites: []config.ImageOverwrite{
+				{Name: constants.ImageNameCSIDriver, Tag: ptr.To("v1.2.0")},
+				{Name: constants.ImageNameCSIDriver, Tag: ptr.To("v1.3.0")},
+			},
+			shootVersion: "1.31.2",
+			want: map[string]string{
+				constants.ImageNameCSIDriver: "synology/synology-csi:v1.3.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vector := testVector()
+
+			images, err := FindImages(WithOverwrites(logr.Discard(), vector, tt.overwrites), tt.shootVersion)
+			if err != nil {
+				t.Fatalf("failed to find images: %v", err)
+			}
+
+			for name, want := range tt.want {
+				if images[name] != want {
+					t.Errorf("expected image %s to be %s, got %s", name, want, images[name])
+				}
+			}
+
+			// the overwrites must not modify the given image vector
+			if *vector[0].Tag != "v1.1.0" || vector[2].Ref == nil {
+				t.Errorf("image vector was modified by the overwrites")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package synology
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// storageClassLocationParameter is the StorageClass parameter selecting the DSM volume the LUNs are created on
+const storageClassLocationParameter = "location"
+
+// VolumeForStorageClass returns the DSM volume the given StorageClass provisions its LUNs on
+func VolumeForStorageClass(sc *storagev1.StorageClass, volumes []Volume) (Volume, bool) {
+	location := path.Clean(sc.Parameters[storageClassLocationParameter])
+	for _, v := range volumes {
+		if path.Clean(v.Path) == location {
+			return v, true
+		}
+	}
+	return Volume{}, false
+}
+
+// GenerateCSIStorageCapacity reports the free space of the given DSM volume as capacity of the given StorageClass.
+// The capacity is available on the nodes of the StorageClass' allowed topologies or on all nodes without topology.
+// The topology of a CSIStorageCapacity is immutable, so it is recreated by the gardener-resource-manager if the
+// topology of the NAS changed.
+func GenerateCSIStorageCapacity(namespace string, sc *storagev1.StorageClass, volume Volume) *storagev1.CSIStorageCapacity {
+	nodeTopology := &metav1.LabelSelector{}
+	for _, term := range sc.AllowedTopologies {
+		for _, requirement := range term.MatchLabelExpressions {
+			nodeTopology.MatchExpressions = append(nodeTopology.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key:      requirement.Key,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   requirement.Values,
+			})
+		}
+	}
+
+	free := resource.NewQuantity(volume.FreeBytes(), resource.BinarySI)
+
+	return &storagev1.CSIStorageCapacity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "synology-" + sc.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "synology-csi",
+			},
+			Annotations: map[string]string{
+				resourcesv1alpha1.DeleteOnInvalidUpdate: "true",
+			},
+		},
+		StorageClassName:  sc.Name,
+		NodeTopology:      nodeTopology,
+		Capacity:          free,
+		MaximumVolumeSize: free,
+	}
+}
+
+// GenerateCapacityConfigMap reports the usage and status of the DSM volumes of each NAS, keyed by volume id. The
+// volumes of further NAS are prefixed with the name of their NAS.
+func GenerateCapacityConfigMap(namespace string, volumes map[string][]Volume, now time.Time) (*corev1.ConfigMap, error) {
+	data := map[string]string{
+		"lastUpdateTime": now.UTC().Format(time.RFC3339),
+	}
+
+	for nas, nasVolumes := range volumes {
+		for _, v := range nasVolumes {
+			status, err := json.Marshal(struct {
+				Volume
+				FreeBytes int64 `json:"freeBytes"`
+			}{v, v.FreeBytes()})
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal status of volume %s: %w", v.ID, err)
+			}
+
+			key := v.ID
+			if nas != "" {
+				key = nas + "." + v.ID
+			}
+			data[key] = string(status)
+		}
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.CapacityConfigMapName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "synology-csi",
+			},
+		},
+		Data: data,
+	}, nil
+}
@@ -1,6 +1,8 @@
 package synology
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/json"
@@ -10,17 +12,48 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// ShootUsernamePrefix is the prefix of the DSM users created for shoot clusters
+const ShootUsernamePrefix = "gardener-"
+
+// sharedTransport is used by all clients, so that connections to the DSM API are pooled across clients
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	// dev-friendly; for production you should validate certs
+	TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// Client is a client of the DSM API. It is safe for concurrent use, logins are serialized and the session is shared
+// by all requests until DSM reports it as expired.
 type Client struct {
 	baseURL    *url.URL
 	username   string
 	password   string
-	sessionID  string
-	synoToken  string
 	httpClient *http.Client
+	limiter    *limiter
+
+	// loginLock serializes logins
+	loginLock sync.Mutex
+	// sessionLock guards the session
+	sessionLock sync.RWMutex
+	session     session
+}
+
+// session is a DSM login session
+type session struct {
+	id    string
+	token string
+}
+
+func (s session) valid() bool {
+	return s.id != "" && s.token != ""
 }
 
 func NewClient(base, username, password string) (*Client, error) {
@@ -37,12 +70,10 @@ func NewClient(base, username, password string) (*Client, error) {
 		username: username,
 		password: password,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				// dev-friendly; for production you should validate certs
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
+			Timeout:   30 * time.Second,
+			Transport: sharedTransport,
 		},
+		limiter: newLimiter(DefaultLimits),
 	}, nil
 }
 
@@ -76,7 +107,14 @@ type simpleResult struct {
 // - session=Core
 // - format=sid
 // - enable_syno_token=yes
-func (c *Client) Login() error {
+func (c *Client) Login(ctx context.Context) error {
+	c.loginLock.Lock()
+	defer c.loginLock.Unlock()
+
+	return c.login(ctx)
+}
+
+func (c *Client) login(ctx context.Context) error {
 	u, err := url.Parse(c.webapiURL("entry.cgi"))
 	if err != nil {
 		return fmt.Errorf("build login url: %w", err)
@@ -93,25 +131,21 @@ func (c *Client) Login() error {
 	q.Set("enable_syno_token", "yes")
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return fmt.Errorf("build login request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do("SYNO.API.Auth", "login", req)
 	if err != nil {
+		loginsTotal.WithLabelValues("failure").Inc()
 		return fmt.Errorf("login request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("read login response: %w", err)
-	}
 
 	var lr loginResponse
 	if err := json.Unmarshal(body, &lr); err != nil {
+		loginsTotal.WithLabelValues("failure").Inc()
 		return fmt.Errorf("parse login response: %w (body=%q)", err, string(body))
 	}
 
@@ -120,6 +154,8 @@ func (c *Client) Login() error {
 		if lr.Error != nil {
 			code = lr.Error.Code
 		}
+		loginsTotal.WithLabelValues("failure").Inc()
+		recordError("SYNO.API.Auth", "login", code)
 		return fmt.Errorf("login failed (code=%d, body=%s)", code, string(body))
 	}
 
@@ -130,16 +166,162 @@ func (c *Client) Login() error {
 		return fmt.Errorf("login succeeded but synotoken is empty (body=%s)", string(body))
 	}
 
-	c.sessionID = lr.Data.SID
-	c.synoToken = lr.Data.SynoToken
+	loginsTotal.WithLabelValues("success").Inc()
+	c.setSession(session{id: lr.Data.SID, token: lr.Data.SynoToken})
 	return nil
 }
 
-func (c *Client) ensureLogin() error {
-	if c.sessionID != "" && c.synoToken != "" {
-		return nil
+// EnsureLogin logs in unless the client has a session already
+func (c *Client) EnsureLogin(ctx context.Context) error {
+	_, err := c.ensureLogin(ctx)
+	return err
+}
+
+// ensureLogin returns the current session, logging in if there is none. Concurrent callers wait for a single login.
+func (c *Client) ensureLogin(ctx context.Context) (session, error) {
+	if sess := c.currentSession(); sess.valid() {
+		return sess, nil
+	}
+
+	c.loginLock.Lock()
+	defer c.loginLock.Unlock()
+
+	// another caller may have logged in while waiting for the lock
+	if sess := c.currentSession(); sess.valid() {
+		return sess, nil
+	}
+
+	sessionRenewalsTotal.Inc()
+	if err := c.login(ctx); err != nil {
+		return session{}, err
+	}
+
+	return c.currentSession(), nil
+}
+
+func (c *Client) currentSession() session {
+	c.sessionLock.RLock()
+	defer c.sessionLock.RUnlock()
+	return c.session
+}
+
+func (c *Client) setSession(sess session) {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+	c.session = sess
+}
+
+// invalidateSession drops the session with the given id, unless it was replaced by a newer one already
+func (c *Client) invalidateSession(id string) {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+	if c.session.id == id {
+		c.session = session{}
+	}
+}
+
+// do sends the request for the given DSM API method and returns the response body. If DSM reports the session of the
+// request as expired, the client logs in again and resends the request once.
+func (c *Client) do(api, method string, req *http.Request) ([]byte, error) {
+	body, err := c.send(api, method, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sid := req.URL.Query().Get("_sid")
+	if sid == "" || !sessionExpired(body) {
+		return body, nil
+	}
+
+	c.invalidateSession(sid)
+	sess, err := c.ensureLogin(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Set("_sid", sess.id)
+	req.URL.RawQuery = q.Encode()
+	if req.Header.Get("X-SYNO-TOKEN") != "" {
+		req.Header.Set("X-SYNO-TOKEN", sess.token)
 	}
-	return c.Login()
+
+	return c.send(api, method, req)
+}
+
+// sessionExpired returns whether the given response reports a timed out or unknown session
+func sessionExpired(body []byte) bool {
+	if !bytes.Contains(body, []byte(`"error"`)) {
+		return false
+	}
+
+	var result simpleResult
+	if err := json.Unmarshal(body, &result); err != nil || result.Success {
+		return false
+	}
+
+	code := extractCode(&result)
+	return code == 106 || code == 119
+}
+
+// send sends the request for the given DSM API method and returns the response body. Requests which failed
+// transiently are retried with backoff, except for creations which DSM may have carried out before failing.
+func (c *Client) send(api, method string, req *http.Request) ([]byte, error) {
+	for retry := 1; ; retry++ {
+		body, transient, err := c.sendOnce(api, method, req)
+		if err == nil || !transient || method == "create" || retry > c.limiter.limits.MaxRetries {
+			return body, err
+		}
+
+		retriesTotal.WithLabelValues(api, method).Inc()
+
+		select {
+		case <-time.After(c.limiter.backoff(retry)):
+		case <-req.Context().Done():
+			return nil, err
+		}
+	}
+}
+
+// sendOnce sends the request within the limits of the NAS, recording the request metrics. It returns whether an error
+// is transient, which is the case for transport errors, timeouts and 5xx or 429 responses.
+func (c *Client) sendOnce(api, method string, req *http.Request) ([]byte, bool, error) {
+	release, err := c.limiter.acquire(req.Context())
+	if err != nil {
+		return nil, false, fmt.Errorf("waiting for rate limit: %w", err)
+	}
+	defer release()
+
+	requestsTotal.WithLabelValues(api, method).Inc()
+	start := time.Now()
+	defer func() {
+		requestDuration.WithLabelValues(api, method).Observe(time.Since(start).Seconds())
+	}()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		errorsTotal.WithLabelValues(api, method, errorCodeTransport).Inc()
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		errorsTotal.WithLabelValues(api, method, errorCodeTransport).Inc()
+		return nil, true, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		errorsTotal.WithLabelValues(api, method, errorCodeHTTP+strconv.Itoa(resp.StatusCode)).Inc()
+		return nil, true, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return body, false, nil
+}
+
+// recordError counts a DSM error response
+func recordError(api, method string, code int) {
+	errorsTotal.WithLabelValues(api, method, strconv.Itoa(code)).Inc()
 }
 
 func decodeResult(body []byte, out any) error {
@@ -158,8 +340,9 @@ func extractCode(r *simpleResult) int {
 
 // CreateUser creates a new user on the Synology NAS.
 // Uses GET + query params (like the working script) and sends X-SYNO-TOKEN.
-func (c *Client) CreateUser(username, password string) error {
-	if err := c.ensureLogin(); err != nil {
+func (c *Client) CreateUser(ctx context.Context, username, password string) error {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
 		return err
 	}
 
@@ -174,25 +357,66 @@ func (c *Client) CreateUser(username, password string) error {
 	q.Set("method", "create")
 	q.Set("name", username)
 	q.Set("password", password)
-	q.Set("_sid", c.sessionID)
+	q.Set("_sid", sess.id)
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return fmt.Errorf("build create user request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-SYNO-TOKEN", c.synoToken)
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do("SYNO.Core.User", "create", req)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	var result simpleResult
+	if err := decodeResult(body, &result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		code := extractCode(&result)
+		recordError("SYNO.Core.User", "create", code)
+		return fmt.Errorf("create user failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	return nil
+}
+
+// DeleteUser deletes a user from the Synology NAS using SYNO.Core.User/delete.
+// A user which does not exist (code 3106) is treated as deleted.
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return fmt.Errorf("build delete user url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Core.User")
+	q.Set("version", "1")
+	q.Set("method", "delete")
+	q.Set("name", username)
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build delete user request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Core.User", "delete", req)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
 	var result simpleResult
@@ -202,7 +426,65 @@ func (c *Client) CreateUser(username, password string) error {
 
 	if !result.Success {
 		code := extractCode(&result)
-		return fmt.Errorf("create user failed with error code: %d (body=%s)", code, string(body))
+		if code == 3106 {
+			return nil
+		}
+
+		recordError("SYNO.Core.User", "delete", code)
+		return fmt.Errorf("delete user failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	return nil
+}
+
+// SetUserDisabled disables or enables a user on the Synology NAS using SYNO.Core.User/set. Disabled users are
+// expired immediately and cannot login.
+func (c *Client) SetUserDisabled(ctx context.Context, username string, disabled bool) error {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return fmt.Errorf("build set user url: %w", err)
+	}
+
+	expired := "normal"
+	if disabled {
+		expired = "now"
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Core.User")
+	q.Set("version", "1")
+	q.Set("method", "set")
+	q.Set("name", username)
+	q.Set("expired", expired)
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build set user request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Core.User", "set", req)
+	if err != nil {
+		return fmt.Errorf("failed to set user: %w", err)
+	}
+
+	var result simpleResult
+	if err := decodeResult(body, &result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		code := extractCode(&result)
+		recordError("SYNO.Core.User", "set", code)
+		return fmt.Errorf("set user failed with error code: %d (body=%s)", code, string(body))
 	}
 
 	return nil
@@ -221,12 +503,20 @@ type getUserResponse struct {
 // (Field set can be expanded as needed.)
 type User struct {
 	Name string `json:"name,omitempty"`
+	// Expired is "now" for disabled users and "normal" otherwise
+	Expired string `json:"expired,omitempty"`
+}
+
+// Disabled returns whether the user is expired and cannot login
+func (u *User) Disabled() bool {
+	return u.Expired == "now"
 }
 
 // GetUser fetches a user by name using SYNO.Core.User/get.
 // Returns (nil, nil) if the user does not exist (code 407).
-func (c *Client) GetUser(username string) (*User, error) {
-	if err := c.ensureLogin(); err != nil {
+func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
 		return nil, err
 	}
 
@@ -240,26 +530,21 @@ func (c *Client) GetUser(username string) (*User, error) {
 	q.Set("version", "1")
 	q.Set("method", "get")
 	q.Set("name", username)
-	q.Set("_sid", c.sessionID)
+	q.Set("additional", `["expired"]`)
+	q.Set("_sid", sess.id)
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("build get user request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-SYNO-TOKEN", c.synoToken)
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do("SYNO.Core.User", "get", req)
 	if err != nil {
 		return nil, fmt.Errorf("get user request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read get user response: %w", err)
-	}
 
 	var r getUserResponse
 	if err := decodeResult(body, &r); err != nil {
@@ -277,6 +562,7 @@ func (c *Client) GetUser(username string) (*User, error) {
 			return nil, nil
 		}
 
+		recordError("SYNO.Core.User", "get", code)
 		return nil, fmt.Errorf("get user failed with error code: %d (body=%s)", code, string(body))
 	}
 
@@ -289,10 +575,453 @@ func (c *Client) GetUser(username string) (*User, error) {
 	return &r.Data.Users[0], nil
 }
 
+type listUsersResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Users []User `json:"users"`
+	} `json:"data"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// ListUsers returns all local users of the NAS using SYNO.Core.User/list.
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return nil, fmt.Errorf("build list users url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Core.User")
+	q.Set("version", "1")
+	q.Set("method", "list")
+	q.Set("offset", "0")
+	q.Set("limit", "-1")
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build list users request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Core.User", "list", req)
+	if err != nil {
+		return nil, fmt.Errorf("list users request failed: %w", err)
+	}
+
+	var r listUsersResponse
+	if err := decodeResult(body, &r); err != nil {
+		return nil, err
+	}
+
+	if !r.Success {
+		code := -1
+		if r.Error != nil {
+			code = r.Error.Code
+		}
+		recordError("SYNO.Core.User", "list", code)
+		return nil, fmt.Errorf("list users failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	return r.Data.Users, nil
+}
+
+// CountShootUsers returns the number of users on the NAS which were created for shoot clusters.
+func (c *Client) CountShootUsers(ctx context.Context) (int, error) {
+	users, err := c.ListUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, u := range users {
+		if strings.HasPrefix(u.Name, ShootUsernamePrefix) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type dsmInfoResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		VersionString string `json:"version_string"`
+	} `json:"data"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// DSMVersion returns the version of the DiskStation Manager, e.g. "DSM 7.2.1-69057 Update 5", using SYNO.DSM.Info/getinfo.
+func (c *Client) DSMVersion(ctx context.Context) (string, error) {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return "", fmt.Errorf("build dsm info url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.DSM.Info")
+	q.Set("version", "2")
+	q.Set("method", "getinfo")
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("build dsm info request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.DSM.Info", "getinfo", req)
+	if err != nil {
+		return "", fmt.Errorf("dsm info request failed: %w", err)
+	}
+
+	var r dsmInfoResponse
+	if err := decodeResult(body, &r); err != nil {
+		return "", err
+	}
+
+	if !r.Success {
+		code := -1
+		if r.Error != nil {
+			code = r.Error.Code
+		}
+		recordError("SYNO.DSM.Info", "getinfo", code)
+		return "", fmt.Errorf("dsm info failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	return r.Data.VersionString, nil
+}
+
+type storageInfoResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Volumes []struct {
+			ID      string `json:"id"`
+			VolPath string `json:"vol_path"`
+			Status  string `json:"status"`
+			Size    struct {
+				Total string `json:"total"`
+				Used  string `json:"used"`
+			} `json:"size"`
+		} `json:"volumes"`
+	} `json:"data"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// Volume is a storage volume of the NAS
+type Volume struct {
+	// ID is the DSM id of the volume, e.g. volume_1
+	ID string `json:"id"`
+	// Path is the mount path of the volume, e.g. /volume1
+	Path string `json:"path"`
+	// Status is the DSM status of the volume, e.g. normal, degraded or crashed
+	Status string `json:"status"`
+	// TotalBytes is the size of the volume
+	TotalBytes int64 `json:"totalBytes"`
+	// UsedBytes is the used space of the volume
+	UsedBytes int64 `json:"usedBytes"`
+}
+
+// FreeBytes returns the free space of the volume
+func (v Volume) FreeBytes() int64 {
+	return max(v.TotalBytes-v.UsedBytes, 0)
+}
+
+// ListVolumes returns the storage volumes of the NAS and their usage using SYNO.Storage.CGI.Storage/load_info.
+func (c *Client) ListVolumes(ctx context.Context) ([]Volume, error) {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return nil, fmt.Errorf("build storage info url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Storage.CGI.Storage")
+	q.Set("version", "1")
+	q.Set("method", "load_info")
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build storage info request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Storage.CGI.Storage", "load_info", req)
+	if err != nil {
+		return nil, fmt.Errorf("storage info request failed: %w", err)
+	}
+
+	var r storageInfoResponse
+	if err := decodeResult(body, &r); err != nil {
+		return nil, err
+	}
+
+	if !r.Success {
+		code := -1
+		if r.Error != nil {
+			code = r.Error.Code
+		}
+		recordError("SYNO.Storage.CGI.Storage", "load_info", code)
+		return nil, fmt.Errorf("storage info failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	volumes := make([]Volume, 0, len(r.Data.Volumes))
+	for _, v := range r.Data.Volumes {
+		total, err := strconv.ParseInt(v.Size.Total, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse total size of volume %s: %w", v.ID, err)
+		}
+		used, err := strconv.ParseInt(v.Size.Used, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse used size of volume %s: %w", v.ID, err)
+		}
+
+		volumes = append(volumes, Volume{
+			ID:         v.ID,
+			Path:       v.VolPath,
+			Status:     v.Status,
+			TotalBytes: total,
+			UsedBytes:  used,
+		})
+	}
+
+	return volumes, nil
+}
+
+type listTargetsResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Targets []struct {
+			TargetID   int    `json:"target_id"`
+			Name       string `json:"name"`
+			IQN        string `json:"iqn"`
+			MappedLUNs []struct {
+				LUNUUID string `json:"lun_uuid"`
+			} `json:"mapped_luns"`
+		} `json:"targets"`
+	} `json:"data"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// Target is an iSCSI target of the NAS
+type Target struct {
+	// TargetID is the DSM id of the target
+	TargetID int
+	// Name is the name of the target
+	Name string
+	// IQN is the iSCSI qualified name of the target
+	IQN string
+	// MappedLUNs are the UUIDs of the LUNs mapped to the target
+	MappedLUNs []string
+}
+
+// ListTargets returns the iSCSI targets of the NAS and their mapped LUNs using SYNO.Core.ISCSI.Target/list.
+func (c *Client) ListTargets(ctx context.Context) ([]Target, error) {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return nil, fmt.Errorf("build list targets url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Core.ISCSI.Target")
+	q.Set("version", "1")
+	q.Set("method", "list")
+	q.Set("additional", `["mapped_lun"]`)
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build list targets request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Core.ISCSI.Target", "list", req)
+	if err != nil {
+		return nil, fmt.Errorf("list targets request failed: %w", err)
+	}
+
+	var r listTargetsResponse
+	if err := decodeResult(body, &r); err != nil {
+		return nil, err
+	}
+
+	if !r.Success {
+		code := -1
+		if r.Error != nil {
+			code = r.Error.Code
+		}
+		recordError("SYNO.Core.ISCSI.Target", "list", code)
+		return nil, fmt.Errorf("list targets failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	targets := make([]Target, 0, len(r.Data.Targets))
+	for _, t := range r.Data.Targets {
+		target := Target{
+			TargetID: t.TargetID,
+			Name:     t.Name,
+			IQN:      t.IQN,
+		}
+		for _, l := range t.MappedLUNs {
+			target.MappedLUNs = append(target.MappedLUNs, l.LUNUUID)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// MapTarget maps the LUN with the given UUID to the given iSCSI targets using SYNO.Core.ISCSI.LUN/map_target.
+func (c *Client) MapTarget(ctx context.Context, lunUUID string, targetIDs []int) error {
+	return c.lunTargets(ctx, "map_target", lunUUID, targetIDs)
+}
+
+// UnmapTarget unmaps the LUN with the given UUID from the given iSCSI targets using SYNO.Core.ISCSI.LUN/unmap_target.
+func (c *Client) UnmapTarget(ctx context.Context, lunUUID string, targetIDs []int) error {
+	return c.lunTargets(ctx, "unmap_target", lunUUID, targetIDs)
+}
+
+func (c *Client) lunTargets(ctx context.Context, method, lunUUID string, targetIDs []int) error {
+	sess, err := c.ensureLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(c.webapiURL("entry.cgi"))
+	if err != nil {
+		return fmt.Errorf("build %s url: %w", method, err)
+	}
+
+	ids := make([]string, 0, len(targetIDs))
+	for _, id := range targetIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	idsJSON, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("encode target ids: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.Core.ISCSI.LUN")
+	q.Set("version", "1")
+	q.Set("method", method)
+	q.Set("uuid", strconv.Quote(lunUUID))
+	q.Set("target_ids", string(idsJSON))
+	q.Set("_sid", sess.id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", method, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-SYNO-TOKEN", sess.token)
+
+	body, err := c.do("SYNO.Core.ISCSI.LUN", method, req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+
+	var result simpleResult
+	if err := decodeResult(body, &result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		code := extractCode(&result)
+		recordError("SYNO.Core.ISCSI.LUN", method, code)
+		return fmt.Errorf("%s failed with error code: %d (body=%s)", method, code, string(body))
+	}
+
+	return nil
+}
+
+type apiInfoResponse struct {
+	Success bool               `json:"success"`
+	Data    map[string]APIInfo `json:"data"`
+	Error   *apiError          `json:"error,omitempty"`
+}
+
+// APIInfo describes a DSM API served by the NAS
+type APIInfo struct {
+	Path       string `json:"path"`
+	MinVersion int    `json:"minVersion"`
+	MaxVersion int    `json:"maxVersion"`
+}
+
+// QueryAPIs returns the DSM APIs served by the NAS keyed by name using SYNO.API.Info/query.
+func (c *Client) QueryAPIs(ctx context.Context) (map[string]APIInfo, error) {
+	u, err := url.Parse(c.webapiURL("query.cgi"))
+	if err != nil {
+		return nil, fmt.Errorf("build api info url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("api", "SYNO.API.Info")
+	q.Set("version", "1")
+	q.Set("method", "query")
+	q.Set("query", "ALL")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build api info request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, err := c.do("SYNO.API.Info", "query", req)
+	if err != nil {
+		return nil, fmt.Errorf("api info request failed: %w", err)
+	}
+
+	var r apiInfoResponse
+	if err := decodeResult(body, &r); err != nil {
+		return nil, err
+	}
+
+	if !r.Success {
+		code := -1
+		if r.Error != nil {
+			code = r.Error.Code
+		}
+		recordError("SYNO.API.Info", "query", code)
+		return nil, fmt.Errorf("api info failed with error code: %d (body=%s)", code, string(body))
+	}
+
+	return r.Data, nil
+}
+
 // Logout ends the session.
 // Uses session=Core and sends X-SYNO-TOKEN (safe) + _sid.
-func (c *Client) Logout() error {
-	if c.sessionID == "" {
+func (c *Client) Logout(ctx context.Context) error {
+	sess := c.currentSession()
+	if sess.id == "" {
 		return nil
 	}
 
@@ -306,28 +1035,25 @@ func (c *Client) Logout() error {
 	q.Set("version", "7")
 	q.Set("method", "logout")
 	q.Set("session", "Core")
-	q.Set("_sid", c.sessionID)
+	q.Set("_sid", sess.id)
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return fmt.Errorf("build logout request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	if c.synoToken != "" {
-		req.Header.Set("X-SYNO-TOKEN", c.synoToken)
+	if sess.token != "" {
+		req.Header.Set("X-SYNO-TOKEN", sess.token)
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		// clear anyway
-		c.sessionID, c.synoToken = "", ""
+	// the session is dropped even if the logout fails
+	c.invalidateSession(sess.id)
+
+	if _, err := c.send("SYNO.API.Auth", "logout", req); err != nil {
 		return fmt.Errorf("logout request failed: %w", err)
 	}
-	_ = resp.Body.Close()
 
-	c.sessionID = ""
-	c.synoToken = ""
 	return nil
 }
 
@@ -350,6 +1076,6 @@ func GenerateRandomPassword(length int) (string, error) {
 // GenerateShootUsername generates a username for a shoot cluster.
 func GenerateShootUsername(shootName, shootNamespace string) string {
 	// keep it simple: Synology usernames are typically restricted; avoid uppercase/specials
-	s := fmt.Sprintf("gardener-%s-%s", shootNamespace, shootName)
+	s := fmt.Sprintf("%s%s-%s", ShootUsernamePrefix, shootNamespace, shootName)
 	return strings.ToLower(s)
 }
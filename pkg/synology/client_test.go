@@ -0,0 +1,413 @@
+package synology
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/synology/fake"
+)
+
+const (
+	testAdmin         = "admin"
+	testAdminPassword = "admin-password"
+)
+
+// newTestClient returns a client of a new fake DSM logged in as admin, the requests are not limited
+func newTestClient(t *testing.T) (*Client, *fake.Server) {
+	t.Helper()
+
+	server := fake.NewServer(testAdmin, testAdminPassword)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(server.URL, testAdmin, testAdminPassword)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	c.limiter = newLimiter(Limits{})
+
+	return c, server
+}
+
+func TestClientUsers(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestClient(t)
+
+	username := GenerateShootUsername("shoot--project--name", "shoot--project--name")
+
+	user, err := c.GetUser(ctx, username)
+	if err != nil {
+		t.Fatalf("failed to get missing user: %v", err)
+	}
+	if user != nil {
+		t.Fatalf("expected missing user to be nil, got %+v", user)
+	}
+
+	if err := c.CreateUser(ctx, username, "password"); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := c.CreateUser(ctx, username, "password"); err == nil {
+		t.Error("expected creating an existing user to fail")
+	}
+
+	user, err = c.GetUser(ctx, username)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if user == nil || user.Name != username {
+		t.Errorf("expected user %s, got %+v", username, user)
+	}
+
+	if err := c.SetUserDisabled(ctx, username, true); err != nil {
+		t.Fatalf("failed to disable user: %v", err)
+	}
+	user, err = c.GetUser(ctx, username)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if user == nil || !user.Disabled() {
+		t.Errorf("expected user to be disabled, got %+v", user)
+	}
+
+	server.AddUser("someone", "password", "users")
+	count, err := c.CountShootUsers(ctx)
+	if err != nil {
+		t.Fatalf("failed to count shoot users: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 shoot user, got %d", count)
+	}
+
+	if err := c.DeleteUser(ctx, username); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, ok := server.User(username); ok {
+		t.Error("expected user to be deleted")
+	}
+	if err := c.DeleteUser(ctx, username); err != nil {
+		t.Errorf("expected deleting a missing user to succeed, got %v", err)
+	}
+}
+
+func TestClientLogin(t *testing.T) {
+	ctx := context.Background()
+	t.Run("invalid credentials", func(t *testing.T) {
+		server := fake.NewServer(testAdmin, testAdminPassword)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, testAdmin, "wrong")
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		if err := c.Login(ctx); err == nil {
+			t.Error("expected login with invalid credentials to fail")
+		}
+	})
+
+	t.Run("session reuse", func(t *testing.T) {
+		c, server := newTestClient(t)
+
+		for range 3 {
+			if _, err := c.ListUsers(ctx); err != nil {
+				t.Fatalf("failed to list users: %v", err)
+			}
+		}
+		if logins := server.Requests("SYNO.API.Auth", "login"); logins != 1 {
+			t.Errorf("expected the requests to share a single login, got %d", logins)
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		c, server := newTestClient(t)
+
+		if _, err := c.DSMVersion(ctx); err != nil {
+			t.Fatalf("failed to get DSM version: %v", err)
+		}
+
+		server.ExpireSessions()
+
+		version, err := c.DSMVersion(ctx)
+		if err != nil {
+			t.Fatalf("failed to get DSM version with an expired session: %v", err)
+		}
+		if version != "DSM 7.2.1-69057 Update 5" {
+			t.Errorf("unexpected DSM version %q", version)
+		}
+		if logins := server.Requests("SYNO.API.Auth", "login"); logins != 2 {
+			t.Errorf("expected 2 logins, got %d", logins)
+		}
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		c, server := newTestClient(t)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for range 10 {
+			wg.Go(func() {
+				if _, err := c.ListUsers(ctx); err != nil {
+					errs <- err
+				}
+			})
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("failed to list users: %v", err)
+		}
+		if logins := server.Requests("SYNO.API.Auth", "login"); logins != 1 {
+			t.Errorf("expected concurrent requests to share a single login, got %d", logins)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		c, server := newTestClient(t)
+
+		if err := c.Login(ctx); err != nil {
+			t.Fatalf("failed to login: %v", err)
+		}
+		if err := c.Logout(ctx); err != nil {
+			t.Fatalf("failed to logout: %v", err)
+		}
+		if _, err := c.DSMVersion(ctx); err != nil {
+			t.Fatalf("failed to get DSM version after logout: %v", err)
+		}
+
+		if logouts := server.Requests("SYNO.API.Auth", "logout"); logouts != 1 {
+			t.Errorf("expected 1 logout, got %d", logouts)
+		}
+		if logins := server.Requests("SYNO.API.Auth", "login"); logins != 2 {
+			t.Errorf("expected 2 logins, got %d", logins)
+		}
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	t.Run("DSM error", func(t *testing.T) {
+		c, server := newTestClient(t)
+		server.SetError("SYNO.Core.User", "list", fake.ErrorCodeUnknown)
+
+		if _, err := c.ListUsers(ctx); err == nil {
+			t.Error("expected listing users to fail")
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		server := fake.NewServer(testAdmin, testAdminPassword)
+		defer server.Close()
+		server.AddUser("user", "password", "users")
+
+		c, err := NewClient(server.URL, "user", "password")
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		if _, err := c.GetUser(ctx, "user"); err != nil {
+			t.Errorf("expected users to get themselves, got %v", err)
+		}
+		if _, err := c.ListUsers(ctx); err == nil {
+			t.Error("expected listing users without administrator privileges to fail")
+		}
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		c, server := newTestClient(t)
+		if err := c.EnsureLogin(ctx); err != nil {
+			t.Fatalf("failed to login: %v", err)
+		}
+		server.SetLatency(time.Second)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := c.ListUsers(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected request to be cancelled with its context, got %v", err)
+		}
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	ctx := context.Background()
+	server := fake.NewServer(testAdmin, testAdminPassword)
+	defer server.Close()
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the proxy answers the first requests with 503 before passing them on to the fake DSM
+	var failures atomic.Int32
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer unavailable.Close()
+
+	c, err := NewClient(unavailable.URL, testAdmin, testAdminPassword)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	c.limiter = newLimiter(Limits{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	failures.Store(2)
+	if _, err := c.ListUsers(ctx); err != nil {
+		t.Fatalf("expected transient failures to be retried, got %v", err)
+	}
+
+	failures.Store(3)
+	if _, err := c.ListUsers(ctx); err == nil {
+		t.Error("expected listing users to fail once the retries are exhausted")
+	}
+
+	failures.Store(1)
+	if err := c.CreateUser(ctx, "user", "password"); err == nil {
+		t.Error("expected creations not to be retried")
+	}
+	if creates := server.Requests("SYNO.Core.User", "create"); creates != 0 {
+		t.Errorf("expected no creation to reach the DSM, got %d", creates)
+	}
+}
+
+func TestClientVolumes(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestClient(t)
+
+	server.SetVolumes(
+		fake.Volume{ID: "volume_1", Path: "/volume1", Status: "normal", TotalBytes: 100, UsedBytes: 40},
+		fake.Volume{ID: "volume_2", Path: "/volume2", Status: "crashed", TotalBytes: 100, UsedBytes: 120},
+	)
+
+	volumes, err := c.ListVolumes(ctx)
+	if err != nil {
+		t.Fatalf("failed to list volumes: %v", err)
+	}
+	want := []Volume{
+		{ID: "volume_1", Path: "/volume1", Status: "normal", TotalBytes: 100, UsedBytes: 40},
+		{ID: "volume_2", Path: "/volume2", Status: "crashed", TotalBytes: 100, UsedBytes: 120},
+	}
+	if !slices.Equal(volumes, want) {
+		t.Errorf("unexpected volumes %+v, want %+v", volumes, want)
+	}
+	if free := volumes[0].FreeBytes(); free != 60 {
+		t.Errorf("expected 60 free bytes, got %d", free)
+	}
+	if free := volumes[1].FreeBytes(); free != 0 {
+		t.Errorf("expected no free bytes of an overfull volume, got %d", free)
+	}
+}
+
+func TestClientTargets(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestClient(t)
+
+	target := server.AddTarget("target")
+	lun := server.AddLUN("lun", "/volume1", 10, target)
+
+	if err := c.UnmapTarget(ctx, lun, []int{target}); err != nil {
+		t.Fatalf("failed to unmap LUN: %v", err)
+	}
+	targets, err := c.ListTargets(ctx)
+	if err != nil {
+		t.Fatalf("failed to list targets: %v", err)
+	}
+	if len(targets) != 1 || len(targets[0].MappedLUNs) != 0 {
+		t.Errorf("expected LUN to be unmapped, got %+v", targets)
+	}
+
+	if err := c.MapTarget(ctx, lun, []int{target}); err != nil {
+		t.Fatalf("failed to map LUN: %v", err)
+	}
+	targets, err = c.ListTargets(ctx)
+	if err != nil {
+		t.Fatalf("failed to list targets: %v", err)
+	}
+	if len(targets) != 1 || !slices.Equal(targets[0].MappedLUNs, []string{lun}) {
+		t.Errorf("expected LUN to be mapped, got %+v", targets)
+	}
+
+	if err := c.MapTarget(ctx, "missing", []int{target}); err == nil {
+		t.Error("expected mapping a missing LUN to fail")
+	}
+}
+
+func TestClientQueryAPIs(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestClient(t)
+
+	apis, err := c.QueryAPIs(ctx)
+	if err != nil {
+		t.Fatalf("failed to query APIs: %v", err)
+	}
+	if info, ok := apis["SYNO.API.Auth"]; !ok || info.MaxVersion != 7 {
+		t.Errorf("expected SYNO.API.Auth with version 7, got %+v", apis)
+	}
+}
+
+func TestClientManager(t *testing.T) {
+	ctx := context.Background()
+	server := fake.NewServer(testAdmin, testAdminPassword)
+	defer server.Close()
+
+	m := NewClientManager(Limits{})
+
+	c, err := m.Get(server.URL, testAdmin, testAdminPassword)
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if cached, _ := m.Get(server.URL, testAdmin, testAdminPassword); cached != c {
+		t.Error("expected the client to be reused")
+	}
+	if err := c.EnsureLogin(ctx); err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+
+	replaced, err := m.Get(server.URL, testAdmin, "rotated")
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if replaced == c {
+		t.Error("expected the client to be replaced after a password change")
+	}
+	if replaced.limiter != c.limiter {
+		t.Error("expected the clients of a NAS to share their limiter")
+	}
+	if logouts := server.Requests("SYNO.API.Auth", "logout"); logouts != 0 {
+		t.Errorf("expected the replaced client to stay logged in, got %d logouts", logouts)
+	}
+
+	// the replaced client was dropped, only the sessions of the managed clients are logged out
+	if err := replaced.EnsureLogin(ctx); err == nil {
+		t.Fatal("expected login with the rotated password to fail")
+	}
+	m.Close(ctx)
+	if logouts := server.Requests("SYNO.API.Auth", "logout"); logouts != 0 {
+		t.Errorf("expected no logout of a client without session, got %d", logouts)
+	}
+
+	c, err = m.Get(server.URL, testAdmin, testAdminPassword)
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if err := c.EnsureLogin(ctx); err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	m.Close(ctx)
+	if logouts := server.Requests("SYNO.API.Auth", "logout"); logouts != 1 {
+		t.Errorf("expected the session to be logged out on close, got %d logouts", logouts)
+	}
+}
@@ -1,22 +1,36 @@
 package synology
 
 import (
+	"fmt"
+	"slices"
+
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	gardenerutils "github.com/gardener/gardener/pkg/utils/gardener"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
 )
 
 // GenerateControllerDeployment generates the CSI controller deployment
-func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
-	replicas := int32(1)
+func GenerateControllerDeployment(config *ManifestConfig) *appsv1.Deployment {
+	replicas := max(config.ControllerReplicas, 1)
+	if config.Suspended {
+		replicas = 0
+	}
+
+	topologyKey := config.ControllerTopologyKey
+	if topologyKey == "" {
+		topologyKey = corev1.LabelHostname
+	}
 
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      constants.ControllerName,
-			Namespace: namespace,
+			Namespace: config.Namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/name":      "synology-csi",
 				"app.kubernetes.io/component": "controller",
@@ -35,21 +49,43 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 					Labels: map[string]string{
 						"app.kubernetes.io/name":      "synology-csi",
 						"app.kubernetes.io/component": "controller",
+						// egress to DNS and the kube-apiserver is allowed by Gardener's network policies in the shoot
+						v1beta1constants.LabelNetworkPolicyToDNS:            v1beta1constants.LabelNetworkPolicyAllowed,
+						v1beta1constants.LabelNetworkPolicyShootToAPIServer: v1beta1constants.LabelNetworkPolicyAllowed,
 					},
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: constants.ControllerName,
 					PriorityClassName:  "system-cluster-critical",
+					SecurityContext:    restrictedPodSecurityContext(),
+					Affinity: &corev1.Affinity{
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+								{
+									Weight: 100,
+									PodAffinityTerm: corev1.PodAffinityTerm{
+										TopologyKey: topologyKey,
+										LabelSelector: &metav1.LabelSelector{
+											MatchLabels: map[string]string{
+												"app.kubernetes.io/name":      "synology-csi",
+												"app.kubernetes.io/component": "controller",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
 					Containers: []corev1.Container{
 						// CSI Driver Container
 						{
 							Name:  "synology-csi-driver",
-							Image: constants.ImageCSIDriver,
+							Image: config.Images[constants.ImageNameCSIDriver],
 							Args: []string{
 								"--nodeid=$(NODE_ID)",
 								"--endpoint=$(CSI_ENDPOINT)",
 								"--client-info=/etc/synology/client-info.yaml",
-								"--log-level=info",
+								config.driverLogLevelArg(),
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -76,22 +112,18 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 									ReadOnly:  true,
 								},
 							},
-							SecurityContext: &corev1.SecurityContext{
-								Privileged:               ptr.To(true),
-								AllowPrivilegeEscalation: ptr.To(true),
-								Capabilities: &corev1.Capabilities{
-									Add: []corev1.Capability{"SYS_ADMIN"},
-								},
-							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 						// CSI Provisioner
 						{
 							Name:  "csi-provisioner",
-							Image: constants.ImageCSIProvisioner,
+							Image: config.Images[constants.ImageNameCSIProvisioner],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--timeout=60s",
-								"--v=5",
+								config.sidecarVerbosityArg(),
+								"--leader-election",
+								"--leader-election-namespace=" + constants.ShootTargetNamespace,
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -105,15 +137,18 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 									MountPath: "/var/lib/csi/sockets/pluginproxy/",
 								},
 							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 						// CSI Attacher
 						{
 							Name:  "csi-attacher",
-							Image: constants.ImageCSIAttacher,
+							Image: config.Images[constants.ImageNameCSIAttacher],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--timeout=60s",
-								"--v=5",
+								config.sidecarVerbosityArg(),
+								"--leader-election",
+								"--leader-election-namespace=" + constants.ShootTargetNamespace,
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -127,15 +162,18 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 									MountPath: "/var/lib/csi/sockets/pluginproxy/",
 								},
 							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 						// CSI Resizer
 						{
 							Name:  "csi-resizer",
-							Image: constants.ImageCSIResizer,
+							Image: config.Images[constants.ImageNameCSIResizer],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--timeout=60s",
-								"--v=5",
+								config.sidecarVerbosityArg(),
+								"--leader-election",
+								"--leader-election-namespace=" + constants.ShootTargetNamespace,
 								"--handle-volume-inuse-error=false",
 							},
 							Env: []corev1.EnvVar{
@@ -150,15 +188,18 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 									MountPath: "/var/lib/csi/sockets/pluginproxy/",
 								},
 							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 						// CSI Snapshotter
 						{
 							Name:  "csi-snapshotter",
-							Image: constants.ImageCSISnapshotter,
+							Image: config.Images[constants.ImageNameCSISnapshotter],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--timeout=60s",
-								"--v=5",
+								config.sidecarVerbosityArg(),
+								"--leader-election",
+								"--leader-election-namespace=" + constants.ShootTargetNamespace,
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -172,11 +213,12 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 									MountPath: "/var/lib/csi/sockets/pluginproxy/",
 								},
 							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 						// Liveness Probe
 						{
 							Name:  "liveness-probe",
-							Image: constants.ImageCSILivenessProbe,
+							Image: config.Images[constants.ImageNameCSILivenessProbe],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--health-port=9808",
@@ -212,6 +254,7 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 								PeriodSeconds:       10,
 								FailureThreshold:    5,
 							},
+							SecurityContext: restrictedSecurityContext(),
 						},
 					},
 					Volumes: []corev1.Volume{
@@ -240,4 +283,70 @@ func GenerateControllerDeployment(namespace string) *appsv1.Deployment {
 			},
 		},
 	}
+
+	if !config.Snapshots {
+		deployment.Spec.Template.Spec.Containers = slices.DeleteFunc(deployment.Spec.Template.Spec.Containers, func(c corev1.Container) bool {
+			return c.Name == "csi-snapshotter"
+		})
+	}
+
+	applyResources(deployment.Spec.Template.Spec.Containers, defaultControllerResources, config.ControllerResources)
+
+	return deployment
+}
+
+// GenerateSeedControllerDeployment generates the CSI controller deployment for the shoot's control plane namespace
+// in the seed. The sidecars talk to the shoot API server through Gardener's generic token kubeconfig.
+func GenerateSeedControllerDeployment(config *ManifestConfig, genericKubeconfigSecretName string) (*appsv1.Deployment, error) {
+	deployment := GenerateControllerDeployment(config)
+
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.ServiceAccountName = ""
+	podSpec.AutomountServiceAccountToken = ptr.To(false)
+	podSpec.PriorityClassName = v1beta1constants.PriorityClassNameShootControlPlane300
+
+	delete(deployment.Spec.Template.Labels, v1beta1constants.LabelNetworkPolicyShootToAPIServer)
+	deployment.Spec.Template.Labels[v1beta1constants.LabelNetworkPolicyToPrivateNetworks] = v1beta1constants.LabelNetworkPolicyAllowed
+	deployment.Spec.Template.Labels[v1beta1constants.LabelNetworkPolicyToPublicNetworks] = v1beta1constants.LabelNetworkPolicyAllowed
+	deployment.Spec.Template.Labels[gardenerutils.NetworkPolicyLabel(v1beta1constants.DeploymentNameKubeAPIServer, 443)] = v1beta1constants.LabelNetworkPolicyAllowed
+
+	sidecars := []string{"csi-provisioner", "csi-attacher", "csi-resizer", "csi-snapshotter"}
+	for i := range podSpec.Containers {
+		if slices.Contains(sidecars, podSpec.Containers[i].Name) {
+			podSpec.Containers[i].Args = append(podSpec.Containers[i].Args, "--kubeconfig="+gardenerutils.PathGenericKubeconfig)
+		}
+	}
+
+	shootAccessSecret := gardenerutils.NewShootAccessSecret(constants.ControllerName, config.Namespace)
+	if err := gardenerutils.InjectGenericKubeconfig(deployment, genericKubeconfigSecretName, shootAccessSecret.Secret.Name, sidecars...); err != nil {
+		return nil, fmt.Errorf("failed to inject generic kubeconfig: %w", err)
+	}
+
+	return deployment, nil
+}
+
+// GenerateControllerPodDisruptionBudget generates the PodDisruptionBudget for the CSI controller
+func GenerateControllerPodDisruptionBudget(namespace string) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt32(1)
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.ControllerName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "synology-csi",
+				"app.kubernetes.io/component": "controller",
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name":      "synology-csi",
+					"app.kubernetes.io/component": "controller",
+				},
+			},
+			UnhealthyPodEvictionPolicy: ptr.To(policyv1.AlwaysAllow),
+		},
+	}
 }
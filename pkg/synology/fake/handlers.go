@@ -0,0 +1,370 @@
+package fake
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type handlerFunc func(method string, r *http.Request) (any, int)
+
+// apis are the APIs served by the emulated NAS with their supported versions
+var apis = map[string][2]int{
+	"SYNO.API.Info":            {1, 1},
+	"SYNO.API.Auth":            {1, 7},
+	"SYNO.DSM.Info":            {1, 2},
+	"SYNO.Core.User":           {1, 1},
+	"SYNO.Core.Group":          {1, 1},
+	"SYNO.Core.Group.Member":   {1, 1},
+	"SYNO.Core.ISCSI.LUN":      {1, 1},
+	"SYNO.Core.ISCSI.Target":   {1, 1},
+	"SYNO.Storage.CGI.Storage": {1, 1},
+}
+
+func (s *Server) handlers() map[string]handlerFunc {
+	return map[string]handlerFunc{
+		"SYNO.DSM.Info":            s.handleDSMInfo,
+		"SYNO.Core.User":           s.handleUser,
+		"SYNO.Core.Group":          s.handleGroup,
+		"SYNO.Core.Group.Member":   s.handleGroupMember,
+		"SYNO.Core.ISCSI.LUN":      s.handleLUN,
+		"SYNO.Core.ISCSI.Target":   s.handleTarget,
+		"SYNO.Storage.CGI.Storage": s.handleStorage,
+	}
+}
+
+func (s *Server) handleAPIInfo(method string) (any, int) {
+	if method != "query" {
+		return nil, ErrorCodeMethodNotFound
+	}
+
+	data := map[string]any{}
+	for name, versions := range apis {
+		data[name] = map[string]any{"path": "entry.cgi", "minVersion": versions[0], "maxVersion": versions[1]}
+	}
+	return data, 0
+}
+
+func (s *Server) handleAuth(method string, r *http.Request) (any, int) {
+	switch method {
+	case "login":
+		user, ok := s.users[r.Form.Get("account")]
+		if !ok || user.Expired || user.Password != r.Form.Get("passwd") {
+			return nil, ErrorCodeInvalidCredentials
+		}
+
+		sid := randomID(16)
+		sess := &session{account: user.Name, token: randomID(8), expires: time.Now().Add(s.sessionTTL)}
+		s.sessions[sid] = sess
+
+		data := map[string]any{"account": user.Name, "sid": sid}
+		if r.Form.Get("enable_syno_token") == "yes" {
+			data["synotoken"] = sess.token
+		}
+		return data, 0
+	case "logout":
+		delete(s.sessions, r.Form.Get("_sid"))
+		return nil, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+func (s *Server) handleDSMInfo(method string, _ *http.Request) (any, int) {
+	if method != "getinfo" {
+		return nil, ErrorCodeMethodNotFound
+	}
+
+	return map[string]any{"version_string": s.dsmVersion}, 0
+}
+
+func (s *Server) handleUser(method string, r *http.Request) (any, int) {
+	name := r.Form.Get("name")
+
+	switch method {
+	case "list":
+		users := []map[string]any{}
+		for _, n := range s.sortedUserNames() {
+			users = append(users, map[string]any{"name": n, "expired": userStatus(s.users[n])})
+		}
+		return map[string]any{"total": len(users), "offset": 0, "users": users}, 0
+	case "get":
+		user, ok := s.users[name]
+		if !ok {
+			return nil, ErrorCodeUserNotFound
+		}
+		return map[string]any{"users": []map[string]any{{"name": user.Name, "expired": userStatus(user)}}}, 0
+	case "create":
+		if name == "" || r.Form.Get("password") == "" {
+			return nil, ErrorCodeInvalidParameter
+		}
+		if _, ok := s.users[name]; ok {
+			return nil, ErrorCodeUserExists
+		}
+		s.users[name] = &User{Name: name, Password: r.Form.Get("password"), Groups: []string{"users"}}
+		return map[string]any{"name": name}, 0
+	case "set":
+		user, ok := s.users[name]
+		if !ok {
+			return nil, ErrorCodeUserNotFound
+		}
+		if password := r.Form.Get("password"); password != "" {
+			user.Password = password
+		}
+		if expired := r.Form.Get("expired"); expired != "" {
+			user.Expired = expired == "now"
+		}
+		return map[string]any{"name": name}, 0
+	case "delete":
+		if _, ok := s.users[name]; !ok {
+			return nil, ErrorCodeUserNotFound
+		}
+		delete(s.users, name)
+		for sid, sess := range s.sessions {
+			if sess.account == name {
+				delete(s.sessions, sid)
+			}
+		}
+		return nil, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+func userStatus(u *User) string {
+	if u.Expired {
+		return "now"
+	}
+	return "normal"
+}
+
+func (s *Server) handleGroup(method string, r *http.Request) (any, int) {
+	switch method {
+	case "list":
+		groups := []map[string]any{}
+		for _, name := range s.sortedGroupNames() {
+			groups = append(groups, map[string]any{"name": name})
+		}
+		return map[string]any{"total": len(groups), "offset": 0, "groups": groups}, 0
+	case "get":
+		name := r.Form.Get("name")
+		if _, ok := s.groups[name]; !ok {
+			return nil, ErrorCodeGroupNotFound
+		}
+		return map[string]any{"groups": []map[string]any{{"name": name}}}, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+func (s *Server) handleGroupMember(method string, r *http.Request) (any, int) {
+	group := r.Form.Get("group")
+	if _, ok := s.groups[group]; !ok {
+		return nil, ErrorCodeGroupNotFound
+	}
+
+	switch method {
+	case "list":
+		users := []map[string]any{}
+		for _, name := range s.sortedUserNames() {
+			if slices.Contains(s.users[name].Groups, group) {
+				users = append(users, map[string]any{"name": name})
+			}
+		}
+		return map[string]any{"total": len(users), "offset": 0, "users": users}, 0
+	case "add", "remove":
+		for _, name := range formList(r, "name") {
+			user, ok := s.users[name]
+			if !ok {
+				return nil, ErrorCodeUserNotFound
+			}
+			user.Groups = slices.DeleteFunc(user.Groups, func(g string) bool { return g == group })
+			if method == "add" {
+				user.Groups = append(user.Groups, group)
+			}
+		}
+		return nil, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+func (s *Server) sortedGroupNames() []string {
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (s *Server) handleLUN(method string, r *http.Request) (any, int) {
+	uuid := strings.Trim(r.Form.Get("uuid"), `"`)
+
+	switch method {
+	case "list":
+		luns := []*LUN{}
+		for _, l := range s.luns {
+			luns = append(luns, l)
+		}
+		slices.SortFunc(luns, func(a, b *LUN) int { return strings.Compare(a.Name, b.Name) })
+		return map[string]any{"luns": luns}, 0
+	case "get":
+		lun, ok := s.luns[uuid]
+		if !ok {
+			return nil, ErrorCodeLUNNotFound
+		}
+		return map[string]any{"lun": lun}, 0
+	case "create":
+		name, location := r.Form.Get("name"), r.Form.Get("location")
+		size, err := strconv.ParseInt(r.Form.Get("size"), 10, 64)
+		if name == "" || err != nil || size <= 0 {
+			return nil, ErrorCodeInvalidParameter
+		}
+
+		volume := slices.IndexFunc(s.volumes, func(v Volume) bool { return v.Path == location })
+		if volume < 0 {
+			return nil, ErrorCodeInvalidParameter
+		}
+		if s.volumes[volume].TotalBytes-s.volumes[volume].UsedBytes < size {
+			return nil, ErrorCodeUnknown
+		}
+		s.volumes[volume].UsedBytes += size
+
+		lun := &LUN{UUID: randomID(16), Name: name, Location: location, Size: size, Type: r.Form.Get("type")}
+		s.luns[lun.UUID] = lun
+		return map[string]any{"uuid": lun.UUID}, 0
+	case "delete":
+		lun, ok := s.luns[uuid]
+		if !ok {
+			return nil, ErrorCodeLUNNotFound
+		}
+		if volume := slices.IndexFunc(s.volumes, func(v Volume) bool { return v.Path == lun.Location }); volume >= 0 {
+			s.volumes[volume].UsedBytes -= lun.Size
+		}
+		delete(s.luns, uuid)
+		return nil, 0
+	case "map_target", "unmap_target":
+		lun, ok := s.luns[uuid]
+		if !ok {
+			return nil, ErrorCodeLUNNotFound
+		}
+		for _, id := range formList(r, "target_ids") {
+			targetID, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, ErrorCodeInvalidParameter
+			}
+			if _, ok := s.targets[targetID]; !ok {
+				return nil, ErrorCodeTargetNotFound
+			}
+			lun.TargetIDs = slices.DeleteFunc(lun.TargetIDs, func(t int) bool { return t == targetID })
+			if method == "map_target" {
+				lun.TargetIDs = append(lun.TargetIDs, targetID)
+			}
+		}
+		return nil, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+func (s *Server) handleTarget(method string, r *http.Request) (any, int) {
+	targetID, _ := strconv.Atoi(strings.Trim(r.Form.Get("target_id"), `"`))
+
+	switch method {
+	case "list":
+		targets := []*Target{}
+		for _, t := range s.targets {
+			targets = append(targets, t)
+		}
+		slices.SortFunc(targets, func(a, b *Target) int { return cmp.Compare(a.TargetID, b.TargetID) })
+
+		data := []map[string]any{}
+		for _, t := range targets {
+			data = append(data, s.targetData(t))
+		}
+		return map[string]any{"targets": data}, 0
+	case "get":
+		target, ok := s.targets[targetID]
+		if !ok {
+			return nil, ErrorCodeTargetNotFound
+		}
+		return map[string]any{"target": s.targetData(target)}, 0
+	case "create":
+		name := r.Form.Get("name")
+		if name == "" {
+			return nil, ErrorCodeInvalidParameter
+		}
+		iqn := r.Form.Get("iqn")
+		if iqn == "" {
+			iqn = fmt.Sprintf("iqn.2000-01.com.synology:fake.%s", name)
+		}
+
+		target := &Target{TargetID: s.nextTargetID, Name: name, IQN: iqn}
+		s.nextTargetID++
+		s.targets[target.TargetID] = target
+		return map[string]any{"target_id": target.TargetID}, 0
+	case "delete":
+		if _, ok := s.targets[targetID]; !ok {
+			return nil, ErrorCodeTargetNotFound
+		}
+		delete(s.targets, targetID)
+		for _, lun := range s.luns {
+			lun.TargetIDs = slices.DeleteFunc(lun.TargetIDs, func(t int) bool { return t == targetID })
+		}
+		return nil, 0
+	default:
+		return nil, ErrorCodeMethodNotFound
+	}
+}
+
+// targetData returns the target as reported by DSM, including the LUNs mapped to it
+func (s *Server) targetData(t *Target) map[string]any {
+	mapped := []map[string]any{}
+	for _, lun := range s.luns {
+		if slices.Contains(lun.TargetIDs, t.TargetID) {
+			mapped = append(mapped, map[string]any{"lun_uuid": lun.UUID})
+		}
+	}
+	slices.SortFunc(mapped, func(a, b map[string]any) int { return strings.Compare(a["lun_uuid"].(string), b["lun_uuid"].(string)) })
+
+	return map[string]any{"target_id": t.TargetID, "name": t.Name, "iqn": t.IQN, "mapped_luns": mapped}
+}
+
+func (s *Server) handleStorage(method string, _ *http.Request) (any, int) {
+	if method != "load_info" {
+		return nil, ErrorCodeMethodNotFound
+	}
+
+	volumes := []map[string]any{}
+	for _, v := range s.volumes {
+		volumes = append(volumes, map[string]any{
+			"id":       v.ID,
+			"vol_path": v.Path,
+			"status":   v.Status,
+			"size": map[string]string{
+				"total": strconv.FormatInt(v.TotalBytes, 10),
+				"used":  strconv.FormatInt(v.UsedBytes, 10),
+			},
+		})
+	}
+	return map[string]any{"volumes": volumes}, 0
+}
+
+// formList returns the values of a list parameter, which DSM accepts as JSON array or as comma separated list
+func formList(r *http.Request, key string) []string {
+	value := strings.Trim(r.Form.Get(key), "[]")
+	if value == "" {
+		return nil
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		values = append(values, strings.Trim(strings.TrimSpace(v), `"`))
+	}
+	return values
+}
@@ -0,0 +1,384 @@
+// Package fake provides an in-process emulation of the DSM web API of a Synology NAS for testing the synology
+// client and the extension without a real NAS.
+package fake
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DSM error codes returned by the emulated API
+const (
+	ErrorCodeUnknown            = 100
+	ErrorCodeInvalidParameter   = 101
+	ErrorCodeAPINotFound        = 102
+	ErrorCodeMethodNotFound     = 103
+	ErrorCodePermissionDenied   = 105
+	ErrorCodeSessionTimeout     = 106
+	ErrorCodeSessionNotFound    = 119
+	ErrorCodeInvalidCredentials = 400
+	ErrorCodeUserExists         = 3100
+	ErrorCodeUserNotFound       = 3106
+	ErrorCodeGroupNotFound      = 3206
+	ErrorCodeLUNNotFound        = 18990510
+	ErrorCodeTargetNotFound     = 18990710
+)
+
+// User is a DSM user of the emulated NAS
+type User struct {
+	Name     string
+	Password string
+	Groups   []string
+	Expired  bool
+}
+
+// LUN is an iSCSI LUN of the emulated NAS
+type LUN struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	Location  string `json:"location"`
+	Size      int64  `json:"size"`
+	Type      string `json:"type"`
+	TargetIDs []int  `json:"-"`
+}
+
+// Target is an iSCSI target of the emulated NAS
+type Target struct {
+	TargetID int    `json:"target_id"`
+	Name     string `json:"name"`
+	IQN      string `json:"iqn"`
+}
+
+// Volume is a storage volume of the emulated NAS
+type Volume struct {
+	ID         string
+	Path       string
+	Status     string
+	TotalBytes int64
+	UsedBytes  int64
+}
+
+type fault struct {
+	code int
+	// remaining is the number of requests the fault is returned for, 0 means until it is cleared
+	remaining int
+}
+
+type session struct {
+	account string
+	token   string
+	expires time.Time
+}
+
+// Server emulates the DSM web API of a Synology NAS with an httptest.Server. The admin user is a member of the
+// administrators group, further users, groups, volumes, errors, latency and the session lifetime are programmable.
+type Server struct {
+	*httptest.Server
+
+	lock sync.Mutex
+
+	dsmVersion string
+	latency    time.Duration
+	sessionTTL time.Duration
+
+	sessions map[string]*session
+	users    map[string]*User
+	groups   map[string]struct{}
+	luns     map[string]*LUN
+	targets  map[int]*Target
+	volumes  []Volume
+	faults   map[string]*fault
+	requests map[string]int
+
+	nextTargetID int
+}
+
+// NewServer starts a fake DSM with the given admin credentials and a single normal volume /volume1 of 1 TiB. The
+// server needs to be closed after use.
+func NewServer(adminUsername, adminPassword string) *Server {
+	s := &Server{
+		dsmVersion: "DSM 7.2.1-69057 Update 5",
+		sessionTTL: time.Hour,
+		sessions:   map[string]*session{},
+		users: map[string]*User{
+			adminUsername: {Name: adminUsername, Password: adminPassword, Groups: []string{"administrators", "users"}},
+		},
+		groups: map[string]struct{}{
+			"administrators": {},
+			"users":          {},
+		},
+		luns:    map[string]*LUN{},
+		targets: map[int]*Target{},
+		volumes: []Volume{
+			{ID: "volume_1", Path: "/volume1", Status: "normal", TotalBytes: 1 << 40},
+		},
+		faults:       map[string]*fault{},
+		requests:     map[string]int{},
+		nextTargetID: 1,
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetDSMVersion sets the version string reported by SYNO.DSM.Info
+func (s *Server) SetDSMVersion(version string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dsmVersion = version
+}
+
+// SetLatency delays every response by the given duration
+func (s *Server) SetLatency(latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.latency = latency
+}
+
+// SetSessionTTL sets the lifetime of new sessions
+func (s *Server) SetSessionTTL(ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sessionTTL = ttl
+}
+
+// ExpireSessions expires all sessions, subsequent requests with their ids fail with ErrorCodeSessionTimeout
+func (s *Server) ExpireSessions() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sess := range s.sessions {
+		sess.expires = time.Time{}
+	}
+}
+
+// SetError makes every request of the given API method fail with the given DSM error code until it is cleared
+func (s *Server) SetError(api, method string, code int) {
+	s.SetErrorTimes(api, method, code, 0)
+}
+
+// SetErrorTimes makes the next given number of requests of the given API method fail with the given DSM error code
+func (s *Server) SetErrorTimes(api, method string, code, times int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults[api+"/"+method] = &fault{code: code, remaining: times}
+}
+
+// ClearErrors removes all programmed errors
+func (s *Server) ClearErrors() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = map[string]*fault{}
+}
+
+// AddUser adds a DSM user which is a member of the given groups, missing groups are created
+func (s *Server) AddUser(name, password string, groups ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, g := range groups {
+		s.groups[g] = struct{}{}
+	}
+	s.users[name] = &User{Name: name, Password: password, Groups: slices.Clone(groups)}
+}
+
+// User returns a copy of the DSM user with the given name
+func (s *Server) User(name string) (User, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return User{}, false
+	}
+	copied := *u
+	copied.Groups = slices.Clone(u.Groups)
+	return copied, true
+}
+
+// UserNames returns the sorted names of all DSM users
+func (s *Server) UserNames() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.sortedUserNames()
+}
+
+// AddGroup adds a DSM group
+func (s *Server) AddGroup(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.groups[name] = struct{}{}
+}
+
+// SetVolumes replaces the storage volumes of the NAS
+func (s *Server) SetVolumes(volumes ...Volume) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.volumes = slices.Clone(volumes)
+}
+
+// LUNs returns copies of all iSCSI LUNs
+func (s *Server) LUNs() []LUN {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	luns := make([]LUN, 0, len(s.luns))
+	for _, l := range s.luns {
+		copied := *l
+		copied.TargetIDs = slices.Clone(l.TargetIDs)
+		luns = append(luns, copied)
+	}
+	slices.SortFunc(luns, func(a, b LUN) int { return cmp.Compare(a.Name, b.Name) })
+	return luns
+}
+
+// AddTarget adds an iSCSI target with the given name and returns its id
+func (s *Server) AddTarget(name string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	target := &Target{TargetID: s.nextTargetID, Name: name, IQN: "iqn.2000-01.com.synology:fake." + name}
+	s.nextTargetID++
+	s.targets[target.TargetID] = target
+	return target.TargetID
+}
+
+// AddLUN adds an iSCSI LUN with the given name on the volume with the given path, which is mapped to the given
+// targets, and returns its UUID. The space of the volume is not accounted for.
+func (s *Server) AddLUN(name, location string, size int64, targetIDs ...int) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	lun := &LUN{UUID: randomID(16), Name: name, Location: location, Size: size, TargetIDs: slices.Clone(targetIDs)}
+	s.luns[lun.UUID] = lun
+	return lun.UUID
+}
+
+// Targets returns copies of all iSCSI targets
+func (s *Server) Targets() []Target {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	targets := make([]Target, 0, len(s.targets))
+	for _, t := range s.targets {
+		targets = append(targets, *t)
+	}
+	slices.SortFunc(targets, func(a, b Target) int { return cmp.Compare(a.TargetID, b.TargetID) })
+	return targets
+}
+
+// Requests returns the number of requests received for the given API method
+func (s *Server) Requests(api, method string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests[api+"/"+method]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	api, method := r.Form.Get("api"), r.Form.Get("method")
+
+	s.lock.Lock()
+	latency := s.latency
+	s.requests[api+"/"+method]++
+	s.lock.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.lock.Lock()
+	data, code := s.handle(api, method, r)
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if code != 0 {
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": false, "error": map[string]int{"code": code}})
+		return
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": data})
+}
+
+// handle dispatches the request to the handler of its API, it returns the response data or a DSM error code
+func (s *Server) handle(api, method string, r *http.Request) (any, int) {
+	if f, ok := s.faults[api+"/"+method]; ok {
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				delete(s.faults, api+"/"+method)
+			}
+		}
+		return nil, f.code
+	}
+
+	switch api {
+	case "SYNO.API.Info":
+		return s.handleAPIInfo(method)
+	case "SYNO.API.Auth":
+		return s.handleAuth(method, r)
+	}
+
+	account, code := s.authenticate(r)
+	if code != 0 {
+		return nil, code
+	}
+
+	handler, ok := s.handlers()[api]
+	if !ok {
+		return nil, ErrorCodeAPINotFound
+	}
+
+	// everything but the DSM info and the own user requires administrator privileges
+	if api != "SYNO.DSM.Info" && !slices.Contains(s.users[account].Groups, "administrators") {
+		if api != "SYNO.Core.User" || method != "get" || r.Form.Get("name") != account {
+			return nil, ErrorCodePermissionDenied
+		}
+	}
+
+	return handler(method, r)
+}
+
+// authenticate returns the account of the session of the request
+func (s *Server) authenticate(r *http.Request) (string, int) {
+	sess, ok := s.sessions[r.Form.Get("_sid")]
+	if !ok {
+		return "", ErrorCodeSessionNotFound
+	}
+	if time.Now().After(sess.expires) {
+		delete(s.sessions, r.Form.Get("_sid"))
+		return "", ErrorCodeSessionTimeout
+	}
+	if token := r.Header.Get("X-SYNO-TOKEN"); token != "" && token != sess.token {
+		return "", ErrorCodeSessionNotFound
+	}
+	if _, ok := s.users[sess.account]; !ok {
+		return "", ErrorCodeSessionNotFound
+	}
+	return sess.account, 0
+}
+
+func (s *Server) sortedUserNames() []string {
+	names := make([]string, 0, len(s.users))
+	for name := range s.users {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
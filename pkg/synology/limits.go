@@ -0,0 +1,90 @@
+package synology
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limits restrict the requests of the clients of a NAS to its DSM API
+type Limits struct {
+	// RequestsPerSecond is the sustained rate of requests to the NAS, unlimited if zero
+	RequestsPerSecond float64
+	// Burst is the number of requests which may exceed the rate at once
+	Burst int
+	// MaxConcurrent is the maximum number of requests in flight to the NAS, unlimited if zero
+	MaxConcurrent int
+	// MaxRetries is the number of retries of requests which failed transiently, zero disables retries
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, it doubles with each further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+}
+
+// DefaultLimits are the limits of clients which are not configured otherwise
+var DefaultLimits = Limits{
+	RequestsPerSecond: 5,
+	Burst:             10,
+	MaxConcurrent:     4,
+	MaxRetries:        3,
+	InitialBackoff:    500 * time.Millisecond,
+	MaxBackoff:        10 * time.Second,
+}
+
+// limiter enforces the limits of the requests to a NAS, it is shared by all clients of the NAS
+type limiter struct {
+	limits Limits
+	rate   *rate.Limiter
+	slots  chan struct{}
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{limits: limits}
+	if limits.RequestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(limits.RequestsPerSecond), max(limits.Burst, 1))
+	}
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return l
+}
+
+// acquire waits for a free request slot and for the rate limit, the returned function releases the slot
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// backoff returns the wait before the given retry, starting at 1. The exponential backoff is jittered between its
+// half and its full value, so that clients failing at the same time do not retry in lockstep.
+func (l *limiter) backoff(retry int) time.Duration {
+	backoff := l.limits.InitialBackoff
+	for i := 1; i < retry && backoff < l.limits.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, l.limits.MaxBackoff)
+
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
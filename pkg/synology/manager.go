@@ -0,0 +1,77 @@
+package synology
+
+import (
+	"context"
+	"sync"
+)
+
+// ClientManager shares clients of the DSM API across reconciliations. Clients are cached by the URL of the NAS and
+// the username, so that their sessions are reused instead of logging in for every reconciliation, which DSM's
+// auto-block punishes. The clients of a NAS share its rate limit. It is safe for concurrent use.
+type ClientManager struct {
+	lock     sync.Mutex
+	limits   Limits
+	clients  map[clientKey]*managedClient
+	limiters map[string]*limiter
+}
+
+type clientKey struct {
+	url      string
+	username string
+}
+
+type managedClient struct {
+	client   *Client
+	password string
+}
+
+// NewClientManager creates an empty ClientManager whose clients keep to the given limits per NAS
+func NewClientManager(limits Limits) *ClientManager {
+	return &ClientManager{
+		limits:   limits,
+		clients:  map[clientKey]*managedClient{},
+		limiters: map[string]*limiter{},
+	}
+}
+
+// Get returns the shared client for the NAS at the given URL and the given credentials. The client is created on
+// first use and replaced if the password changed. The replaced client is not logged out, as reconciliations which
+// got it before might still use it, its session expires on the DSM instead.
+func (m *ClientManager) Get(url, username, password string) (*Client, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := clientKey{url: url, username: username}
+	if cached, ok := m.clients[key]; ok && cached.password == password {
+		return cached.client, nil
+	}
+
+	c, err := NewClient(url, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	l, ok := m.limiters[c.baseURL.Host]
+	if !ok {
+		l = newLimiter(m.limits)
+		m.limiters[c.baseURL.Host] = l
+	}
+	c.limiter = l
+
+	m.clients[key] = &managedClient{client: c, password: password}
+	return c, nil
+}
+
+// Close logs out the sessions of all clients within the given context and empties the manager
+func (m *ClientManager) Close(ctx context.Context) {
+	m.lock.Lock()
+	clients := m.clients
+	m.clients = map[clientKey]*managedClient{}
+	m.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, cached := range clients {
+		wg.Go(func() { _ = cached.client.Logout(ctx) })
+	}
+	wg.Wait()
+}
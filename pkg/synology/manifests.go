@@ -7,9 +7,10 @@ import (
 	"strconv"
 	"strings"
 
+	resourcesv1alpha1 "github.com/gardener/gardener/pkg/apis/resources/v1alpha1"
+	volumesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
-	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +27,20 @@ type ClientConfig struct {
 	Password string
 }
 
+// NASConfig describes a further NAS the volumes can be provisioned on.
+type NASConfig struct {
+	// Name is the name of the NAS, its StorageClass is named after it.
+	Name string
+	// Host is the DSM host of the NAS, it must be among the clients.
+	Host string
+	// Location is the DSM volume the LUNs are created on.
+	Location string
+	// TopologyKey is the node label describing the location of a node, the NAS is reachable from all nodes if empty.
+	TopologyKey string
+	// TopologyValues are the values of the topology label of the nodes which can reach the NAS.
+	TopologyValues []string
+}
+
 // ManifestConfig contains configuration for generating manifests
 type ManifestConfig struct {
 	Namespace string
@@ -37,6 +52,90 @@ type ManifestConfig struct {
 
 	// Helm-like multi-client config (preferred).
 	Clients []ClientConfig
+
+	// Images maps the image vector names to the images deployed for the shoot.
+	Images map[string]string
+
+	// ControllerReplicas is the number of CSI controller replicas.
+	ControllerReplicas int32
+	// Suspended scales the CSI controller to zero while the shoot is suspended.
+	Suspended bool
+	// ControllerTopologyKey is the topology key the CSI controller replicas are spread across.
+	ControllerTopologyKey string
+
+	// ControllerResources overwrites the resource requirements of the CSI controller containers.
+	ControllerResources map[string]corev1.ResourceRequirements
+	// NodeResources overwrites the resource requirements of the CSI node containers.
+	NodeResources map[string]corev1.ResourceRequirements
+	// VPAEnabled generates VerticalPodAutoscalers for the CSI workloads.
+	VPAEnabled bool
+
+	// NodeWorkerPools restricts the CSI node plugin to the given worker pools, all pools if empty.
+	NodeWorkerPools []string
+	// NodeTolerations are added to the default tolerations of the CSI node plugin.
+	NodeTolerations []corev1.Toleration
+
+	// Snapshots deploys the VolumeSnapshotClass and the csi-snapshotter, the shoot must serve the volume snapshot API.
+	Snapshots bool
+	// SnapshotClassDeletionPolicy is the deletion policy of the VolumeSnapshotClass, defaults to Delete.
+	SnapshotClassDeletionPolicy string
+	// SnapshotClassParameters are the DSM snapshot parameters of the VolumeSnapshotClass.
+	SnapshotClassParameters map[string]string
+
+	// TopologyKey is the node label describing the location of a node, topology is disabled if empty.
+	TopologyKey string
+	// TopologyValues are the values of the topology label of the nodes which can reach the NAS.
+	TopologyValues []string
+	// AdditionalNAS are further NAS the volumes can be provisioned on, each gets a StorageClass of its own.
+	AdditionalNAS []NASConfig
+
+	// DriverLogLevel is the log level of the Synology CSI driver, defaults to info.
+	DriverLogLevel string
+	// SidecarVerbosity is the klog verbosity of the CSI sidecars.
+	SidecarVerbosity int32
+
+	// DSMEgressCIDRs are the CIDRs of the DSM hosts the CSI pods may reach on the DSM ports.
+	DSMEgressCIDRs []string
+	// AdditionalEgressCIDRs are CIDRs the CSI pods may reach on any port.
+	AdditionalEgressCIDRs []string
+
+	// StorageCapacity enables storage capacity tracking on the CSIDriver, the CSIStorageCapacity objects are
+	// published by the extension.
+	StorageCapacity bool
+}
+
+// driverLogLevelArg returns the log level flag of the Synology CSI driver
+func (c *ManifestConfig) driverLogLevelArg() string {
+	logLevel := c.DriverLogLevel
+	if logLevel == "" {
+		logLevel = constants.DefaultDriverLogLevel
+	}
+	return "--log-level=" + logLevel
+}
+
+// nodeTopologies returns the node labels of the nodes which can reach each NAS, nil if a NAS is reachable from all
+// nodes
+func (c *ManifestConfig) nodeTopologies() []corev1.NodeSelectorRequirement {
+	if c.TopologyKey == "" {
+		return nil
+	}
+
+	requirements := []corev1.NodeSelectorRequirement{
+		{Key: c.TopologyKey, Operator: corev1.NodeSelectorOpIn, Values: c.TopologyValues},
+	}
+	for _, nas := range c.AdditionalNAS {
+		if nas.TopologyKey == "" {
+			return nil
+		}
+		requirements = append(requirements, corev1.NodeSelectorRequirement{Key: nas.TopologyKey, Operator: corev1.NodeSelectorOpIn, Values: nas.TopologyValues})
+	}
+
+	return requirements
+}
+
+// sidecarVerbosityArg returns the klog verbosity flag of the CSI sidecars
+func (c *ManifestConfig) sidecarVerbosityArg() string {
+	return fmt.Sprintf("--v=%d", c.SidecarVerbosity)
 }
 
 // GenerateNamespace generates the namespace for the CSI driver
@@ -210,6 +309,53 @@ func GenerateClusterRoleBinding(name, namespace, serviceAccount string) *rbacv1.
 	}
 }
 
+// GenerateControllerLeaderElectionRole generates the role for the leader election of the controller sidecars
+func GenerateControllerLeaderElectionRole(namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.ControllerName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "synology-csi",
+				"app.kubernetes.io/component": "controller",
+			},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"get", "watch", "list", "delete", "update", "create"},
+			},
+		},
+	}
+}
+
+// GenerateRoleBinding generates the role binding
+func GenerateRoleBinding(name, namespace, serviceAccount string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "synology-csi",
+				"app.kubernetes.io/component": name,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      serviceAccount,
+				Namespace: namespace,
+			},
+		},
+	}
+}
+
 // buildClientInfoYAML renders the Helm-like client-info.yaml content.
 func buildClientInfoYAML(clients []ClientConfig) (string, error) {
 	if len(clients) == 0 {
@@ -337,7 +483,7 @@ func GenerateSecret(config *ManifestConfig) (*corev1.Secret, error) {
 }
 
 // GenerateCSIDriver generates the CSIDriver resource
-func GenerateCSIDriver() *storagev1.CSIDriver {
+func GenerateCSIDriver(config *ManifestConfig) *storagev1.CSIDriver {
 	return &storagev1.CSIDriver{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: constants.CSIDriverName,
@@ -346,8 +492,9 @@ func GenerateCSIDriver() *storagev1.CSIDriver {
 			},
 		},
 		Spec: storagev1.CSIDriverSpec{
-			AttachRequired: ptr.To(true),
-			PodInfoOnMount: ptr.To(false),
+			AttachRequired:  ptr.To(true),
+			PodInfoOnMount:  ptr.To(false),
+			StorageCapacity: ptr.To(config.StorageCapacity),
 			VolumeLifecycleModes: []storagev1.VolumeLifecycleMode{
 				storagev1.VolumeLifecyclePersistent,
 			},
@@ -355,20 +502,54 @@ func GenerateCSIDriver() *storagev1.CSIDriver {
 	}
 }
 
+// StorageClassName returns the name of the StorageClass of the NAS with the given name, the NAS of the configuration
+// has no name
+func StorageClassName(nas string) string {
+	if nas == "" {
+		return constants.StorageClassName
+	}
+	return constants.StorageClassName + "-" + nas
+}
+
+// GenerateStorageClasses generates the default StorageClass and a StorageClass for each further NAS
+func GenerateStorageClasses(config *ManifestConfig) []*storagev1.StorageClass {
+	storageClasses := []*storagev1.StorageClass{GenerateStorageClass(config)}
+
+	for _, nas := range config.AdditionalNAS {
+		storageClass := newStorageClass(StorageClassName(nas.Name), nas.TopologyKey, nas.TopologyValues)
+		storageClass.Parameters["dsm"] = nas.Host
+		if nas.Location != "" {
+			storageClass.Parameters[storageClassLocationParameter] = nas.Location
+		}
+		storageClasses = append(storageClasses, storageClass)
+	}
+
+	return storageClasses
+}
+
 // GenerateStorageClass generates the default StorageClass
-func GenerateStorageClass(namespace string) *storagev1.StorageClass {
+func GenerateStorageClass(config *ManifestConfig) *storagev1.StorageClass {
+	storageClass := newStorageClass(StorageClassName(""), config.TopologyKey, config.TopologyValues)
+	storageClass.Annotations["storageclass.kubernetes.io/is-default-class"] = "true"
+	return storageClass
+}
+
+// newStorageClass returns a StorageClass with the default parameters. With topology, volumes are only provisioned for
+// pods scheduled to nodes which can reach the NAS. The parameters, binding mode and topology of a StorageClass are
+// immutable, so it is recreated by the gardener-resource-manager if they changed.
+func newStorageClass(name, topologyKey string, topologyValues []string) *storagev1.StorageClass {
 	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
 	volumeBindingMode := storagev1.VolumeBindingImmediate
 	allowVolumeExpansion := true
 
-	return &storagev1.StorageClass{
+	storageClass := &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "synology-iscsi",
+			Name: name,
 			Labels: map[string]string{
 				"app.kubernetes.io/name": "synology-csi",
 			},
 			Annotations: map[string]string{
-				"storageclass.kubernetes.io/is-default-class": "true",
+				resourcesv1alpha1.DeleteOnInvalidUpdate: "true",
 			},
 		},
 		Provisioner:          constants.CSIDriverName,
@@ -380,10 +561,49 @@ func GenerateStorageClass(namespace string) *storagev1.StorageClass {
 			"fsType":           "ext4",
 			"formatOptions":    "--no-discard",
 			"mountPermissions": "0750",
-			"location":         "/volume1",
+			"location":         constants.DefaultVolumeLocation,
 			"dsm":              "172.18.0.2",
 		},
 	}
+
+	if topologyKey != "" {
+		storageClass.VolumeBindingMode = ptr.To(storagev1.VolumeBindingWaitForFirstConsumer)
+		storageClass.AllowedTopologies = []corev1.TopologySelectorTerm{
+			{
+				MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+					{
+						Key:    topologyKey,
+						Values: topologyValues,
+					},
+				},
+			},
+		}
+	}
+
+	return storageClass
+}
+
+// GenerateVolumeSnapshotClass generates the default VolumeSnapshotClass
+func GenerateVolumeSnapshotClass(config *ManifestConfig) *volumesnapshotv1.VolumeSnapshotClass {
+	deletionPolicy := volumesnapshotv1.VolumeSnapshotContentDelete
+	if config.SnapshotClassDeletionPolicy != "" {
+		deletionPolicy = volumesnapshotv1.DeletionPolicy(config.SnapshotClassDeletionPolicy)
+	}
+
+	return &volumesnapshotv1.VolumeSnapshotClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: constants.SnapshotClassName,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "synology-csi",
+			},
+			Annotations: map[string]string{
+				"snapshot.storage.kubernetes.io/is-default-class": "true",
+			},
+		},
+		Driver:         constants.CSIDriverName,
+		DeletionPolicy: deletionPolicy,
+		Parameters:     config.SnapshotClassParameters,
+	}
 }
 
 // GenerateService generates a service for the CSI controller
@@ -413,29 +633,3 @@ func GenerateService(namespace string) *corev1.Service {
 		},
 	}
 }
-
-// GenerateEgressNetworkPolicyToDSM allows egress from synology-csi pods.
-func GenerateAllowAllEgressNetworkPolicy(namespace string) *networkingv1.NetworkPolicy {
-	return &networkingv1.NetworkPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "allow-all-egress-synology-csi",
-			Namespace: namespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/name": "synology-csi",
-			},
-		},
-		Spec: networkingv1.NetworkPolicySpec{
-			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app.kubernetes.io/name": "synology-csi",
-				},
-			},
-			PolicyTypes: []networkingv1.PolicyType{
-				networkingv1.PolicyTypeEgress,
-			},
-			Egress: []networkingv1.NetworkPolicyEgressRule{
-				{},
-			},
-		},
-	}
-}
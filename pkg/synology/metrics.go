@@ -0,0 +1,105 @@
+package synology
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "csi_driver_synology"
+	metricsSubsystem = "dsm"
+
+	// errorCodeTransport is the error code label of requests which did not get a DSM response
+	errorCodeTransport = "transport"
+	// errorCodeHTTP prefixes the HTTP status code in the error code label of requests rejected by the web server
+	errorCodeHTTP = "http_"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests sent to the DSM API.",
+		},
+		[]string{"api", "method"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests sent to the DSM API.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"api", "method"},
+	)
+
+	errorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "errors_total",
+			Help:      "Number of failed requests to the DSM API by DSM error code.",
+		},
+		[]string{"api", "method", "code"},
+	)
+
+	retriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "retries_total",
+			Help:      "Number of requests to the DSM API retried after a transient failure.",
+		},
+		[]string{"api", "method"},
+	)
+
+	loginsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "logins_total",
+			Help:      "Number of logins to the DSM API.",
+		},
+		[]string{"result"},
+	)
+
+	sessionRenewalsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "session_renewals_total",
+			Help:      "Number of logins to the DSM API because a session was missing or expired.",
+		},
+	)
+
+	managedUsers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "managed_users",
+			Help:      "Number of shoot users managed by the extension on the NAS.",
+		},
+		[]string{"host"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		requestsTotal,
+		requestDuration,
+		errorsTotal,
+		retriesTotal,
+		loginsTotal,
+		sessionRenewalsTotal,
+		managedUsers,
+	)
+}
+
+// SetManagedUsers records the number of shoot users managed by the extension on the NAS of the given host
+func SetManagedUsers(host string, count int) {
+	managedUsers.WithLabelValues(host).Set(float64(count))
+}
@@ -0,0 +1,111 @@
+package synology
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+// anyDestinationCIDRs allow egress to every destination, used if a DSM host cannot be resolved
+var anyDestinationCIDRs = []string{"0.0.0.0/0", "::/0"}
+
+// Resolver resolves the hostnames of the DSM hosts, e.g. net.DefaultResolver
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// ResolveDSMEgressCIDRs returns the single-address CIDRs of the DSM hosts of the given clients. Hosts which are
+// IP addresses are used as they are, hostnames are resolved by the given resolver and left unresolved without one.
+// If a hostname cannot be resolved, the DSM ports are opened to any destination and the unresolved hosts are returned.
+func ResolveDSMEgressCIDRs(ctx context.Context, resolver Resolver, clients []ClientConfig) (cidrs []string, unresolved []string) {
+	for _, c := range clients {
+		if addr, err := netip.ParseAddr(c.Host); err == nil {
+			cidrs = append(cidrs, netip.PrefixFrom(addr, addr.BitLen()).String())
+			continue
+		}
+
+		if resolver == nil {
+			unresolved = append(unresolved, c.Host)
+			continue
+		}
+
+		addrs, err := resolver.LookupNetIP(ctx, "ip", c.Host)
+		if err != nil || len(addrs) == 0 {
+			unresolved = append(unresolved, c.Host)
+			continue
+		}
+
+		for _, addr := range addrs {
+			addr = addr.Unmap()
+			cidrs = append(cidrs, netip.PrefixFrom(addr, addr.BitLen()).String())
+		}
+	}
+
+	if len(unresolved) > 0 {
+		cidrs = slices.Clone(anyDestinationCIDRs)
+	}
+
+	slices.Sort(cidrs)
+	return slices.Compact(cidrs), unresolved
+}
+
+// GenerateEgressNetworkPolicy restricts the egress of the synology-csi pods to the DSM API and iSCSI ports of the
+// DSM hosts and to the additional CIDRs. Egress to DNS and the kube-apiserver is granted by Gardener's network
+// policies through the pod labels.
+func GenerateEgressNetworkPolicy(config *ManifestConfig) *networkingv1.NetworkPolicy {
+	dsmPorts := []int{constants.ISCSIPort}
+	for _, c := range config.Clients {
+		dsmPorts = append(dsmPorts, c.Port)
+	}
+	slices.Sort(dsmPorts)
+	dsmPorts = slices.Compact(dsmPorts)
+
+	dsmRule := networkingv1.NetworkPolicyEgressRule{}
+	for _, port := range dsmPorts {
+		dsmRule.Ports = append(dsmRule.Ports, networkingv1.NetworkPolicyPort{
+			Protocol: ptr.To(corev1.ProtocolTCP),
+			Port:     ptr.To(intstr.FromInt32(int32(port))),
+		})
+	}
+	for _, cidr := range config.DSMEgressCIDRs {
+		dsmRule.To = append(dsmRule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+
+	egress := []networkingv1.NetworkPolicyEgressRule{dsmRule}
+
+	if len(config.AdditionalEgressCIDRs) > 0 {
+		additionalRule := networkingv1.NetworkPolicyEgressRule{}
+		for _, cidr := range config.AdditionalEgressCIDRs {
+			additionalRule.To = append(additionalRule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, additionalRule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "allow-egress-synology-csi",
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "synology-csi",
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name": "synology-csi",
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeEgress,
+			},
+			Egress: egress,
+		},
+	}
+}
@@ -1,6 +1,9 @@
 package synology
 
 import (
+	"slices"
+
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
 	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -10,15 +13,15 @@ import (
 )
 
 // GenerateNodeDaemonSet generates the CSI node DaemonSet
-func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
+func GenerateNodeDaemonSet(config *ManifestConfig) *appsv1.DaemonSet {
 	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
 	hostPathDirectory := corev1.HostPathDirectory
 	bidirectional := corev1.MountPropagationBidirectional
 
-	return &appsv1.DaemonSet{
+	daemonSet := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      constants.NodeName,
-			Namespace: namespace,
+			Namespace: config.Namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/name":      "synology-csi",
 				"app.kubernetes.io/component": "node",
@@ -52,12 +55,12 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 						// CSI Driver Container
 						{
 							Name:  "synology-csi-driver",
-							Image: constants.ImageCSIDriver,
+							Image: config.Images[constants.ImageNameCSIDriver],
 							Args: []string{
 								"--nodeid=$(NODE_ID)",
 								"--endpoint=$(CSI_ENDPOINT)",
 								"--client-info=/etc/synology/client-info.yaml",
-								"--log-level=info",
+								config.driverLogLevelArg(),
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -73,6 +76,7 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 									Value: "unix://csi/csi.sock",
 								},
 							},
+							// the node plugin logs into iSCSI targets and mounts volumes on the host
 							SecurityContext: &corev1.SecurityContext{
 								Privileged:               ptr.To(true),
 								AllowPrivilegeEscalation: ptr.To(true),
@@ -108,11 +112,11 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 						// CSI Node Driver Registrar
 						{
 							Name:  "csi-node-driver-registrar",
-							Image: constants.ImageCSINodeDriverRegistrar,
+							Image: config.Images[constants.ImageNameCSINodeDriverRegistrar],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--kubelet-registration-path=$(DRIVER_REG_SOCK_PATH)",
-								"--v=5",
+								config.sidecarVerbosityArg(),
 							},
 							Env: []corev1.EnvVar{
 								{
@@ -148,11 +152,12 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 								TimeoutSeconds:      5,
 								PeriodSeconds:       10,
 							},
+							SecurityContext: nodeSidecarSecurityContext(),
 						},
 						// Liveness Probe
 						{
 							Name:  "liveness-probe",
-							Image: constants.ImageCSILivenessProbe,
+							Image: config.Images[constants.ImageNameCSILivenessProbe],
 							Args: []string{
 								"--csi-address=$(ADDRESS)",
 								"--health-port=9809",
@@ -188,6 +193,7 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 								PeriodSeconds:       10,
 								FailureThreshold:    5,
 							},
+							SecurityContext: nodeSidecarSecurityContext(),
 						},
 					},
 					Volumes: []corev1.Volume{
@@ -255,4 +261,59 @@ func GenerateNodeDaemonSet(namespace string) *appsv1.DaemonSet {
 			},
 		},
 	}
+
+	applyResources(daemonSet.Spec.Template.Spec.Containers, defaultNodeResources, config.NodeResources)
+
+	podSpec := &daemonSet.Spec.Template.Spec
+	podSpec.Tolerations = append(defaultNodeTolerations(), config.NodeTolerations...)
+
+	var nodeRequirements []corev1.NodeSelectorRequirement
+	if len(config.NodeWorkerPools) > 0 {
+		nodeRequirements = append(nodeRequirements, corev1.NodeSelectorRequirement{
+			Key:      v1beta1constants.LabelWorkerPool,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   config.NodeWorkerPools,
+		})
+	}
+
+	var nodeSelectorTerms []corev1.NodeSelectorTerm
+	// with topology, only nodes which can reach one of the NAS get the node plugin
+	for _, topology := range config.nodeTopologies() {
+		nodeSelectorTerms = append(nodeSelectorTerms, corev1.NodeSelectorTerm{
+			MatchExpressions: append(slices.Clone(nodeRequirements), topology),
+		})
+	}
+	if len(nodeSelectorTerms) == 0 && len(nodeRequirements) > 0 {
+		nodeSelectorTerms = []corev1.NodeSelectorTerm{{MatchExpressions: nodeRequirements}}
+	}
+
+	if len(nodeSelectorTerms) > 0 {
+		podSpec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: nodeSelectorTerms,
+				},
+			},
+		}
+	}
+
+	return daemonSet
+}
+
+// defaultNodeTolerations lets the node plugin run on tainted nodes, like the node plugins of other Gardener CSI drivers.
+func defaultNodeTolerations() []corev1.Toleration {
+	return []corev1.Toleration{
+		{
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		},
+		{
+			Key:      "CriticalAddonsOnly",
+			Operator: corev1.TolerationOpExists,
+		},
+		{
+			Effect:   corev1.TaintEffectNoExecute,
+			Operator: corev1.TolerationOpExists,
+		},
+	}
 }
@@ -0,0 +1,97 @@
+package synology
+
+import (
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpaautoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/utils/ptr"
+)
+
+var (
+	// defaultControllerResources are the resource requirements of the CSI controller containers
+	defaultControllerResources = map[string]corev1.ResourceRequirements{
+		"synology-csi-driver": containerResources("10m", "32Mi", "256Mi"),
+		"csi-provisioner":     containerResources("10m", "32Mi", "128Mi"),
+		"csi-attacher":        containerResources("10m", "32Mi", "128Mi"),
+		"csi-resizer":         containerResources("10m", "32Mi", "128Mi"),
+		"csi-snapshotter":     containerResources("10m", "32Mi", "128Mi"),
+		"liveness-probe":      containerResources("5m", "16Mi", "64Mi"),
+	}
+
+	// defaultNodeResources are the resource requirements of the CSI node containers
+	defaultNodeResources = map[string]corev1.ResourceRequirements{
+		"synology-csi-driver":       containerResources("20m", "64Mi", "512Mi"),
+		"csi-node-driver-registrar": containerResources("5m", "16Mi", "64Mi"),
+		"liveness-probe":            containerResources("5m", "16Mi", "64Mi"),
+	}
+)
+
+func containerResources(cpu, memory, memoryLimit string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse(memoryLimit),
+		},
+	}
+}
+
+// applyResources sets the resource requirements of the given containers, overwrites take precedence over the defaults.
+func applyResources(containers []corev1.Container, defaults, overwrites map[string]corev1.ResourceRequirements) {
+	for i := range containers {
+		if resources, ok := overwrites[containers[i].Name]; ok {
+			containers[i].Resources = *resources.DeepCopy()
+			continue
+		}
+		if resources, ok := defaults[containers[i].Name]; ok {
+			containers[i].Resources = *resources.DeepCopy()
+		}
+	}
+}
+
+// GenerateControllerVPA generates the VerticalPodAutoscaler for the CSI controller
+func GenerateControllerVPA(namespace string) *vpaautoscalingv1.VerticalPodAutoscaler {
+	return generateVPA(constants.ControllerName, namespace, "controller", "Deployment")
+}
+
+// GenerateNodeVPA generates the VerticalPodAutoscaler for the CSI node plugin
+func GenerateNodeVPA(namespace string) *vpaautoscalingv1.VerticalPodAutoscaler {
+	return generateVPA(constants.NodeName, namespace, "node", "DaemonSet")
+}
+
+func generateVPA(name, namespace, component, kind string) *vpaautoscalingv1.VerticalPodAutoscaler {
+	return &vpaautoscalingv1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "synology-csi",
+				"app.kubernetes.io/component": component,
+			},
+		},
+		Spec: vpaautoscalingv1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       kind,
+				Name:       name,
+			},
+			UpdatePolicy: &vpaautoscalingv1.PodUpdatePolicy{
+				UpdateMode: ptr.To(vpaautoscalingv1.UpdateModeAuto),
+			},
+			ResourcePolicy: &vpaautoscalingv1.PodResourcePolicy{
+				ContainerPolicies: []vpaautoscalingv1.ContainerResourcePolicy{
+					{
+						ContainerName:    vpaautoscalingv1.DefaultContainerResourcePolicy,
+						ControlledValues: ptr.To(vpaautoscalingv1.ContainerControlledValuesRequestsOnly),
+					},
+				},
+			},
+		},
+	}
+}
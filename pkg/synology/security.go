@@ -0,0 +1,50 @@
+package synology
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// nonRootUser is the user and group the unprivileged CSI containers run as
+const nonRootUser = 65532
+
+// restrictedPodSecurityContext complies with the restricted pod security standard
+func restrictedPodSecurityContext() *corev1.PodSecurityContext {
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: ptr.To(true),
+		RunAsUser:    ptr.To[int64](nonRootUser),
+		RunAsGroup:   ptr.To[int64](nonRootUser),
+		FSGroup:      ptr.To[int64](nonRootUser),
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// restrictedSecurityContext is the security context of containers which neither need privileges nor write to
+// their root filesystem
+func restrictedSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             ptr.To(true),
+		AllowPrivilegeEscalation: ptr.To(false),
+		ReadOnlyRootFilesystem:   ptr.To(true),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
+
+// nodeSidecarSecurityContext is the security context of the node plugin sidecars. They run as root to create their
+// sockets in the kubelet's host directories, but need no capabilities and keep the runtime's seccomp profile.
+func nodeSidecarSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		ReadOnlyRootFilesystem:   ptr.To(true),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
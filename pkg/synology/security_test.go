@@ -0,0 +1,134 @@
+package synology
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// containerSecurity is the effective security context of a container, settings of the container override the ones
+// of its pod
+type containerSecurity struct {
+	privileged             bool
+	runAsNonRoot           bool
+	readOnlyRootFilesystem bool
+	dropsAllCapabilities   bool
+	seccompProfile         corev1.SeccompProfileType
+}
+
+func effectiveSecurity(pod *corev1.PodSecurityContext, sc *corev1.SecurityContext) containerSecurity {
+	var s containerSecurity
+
+	if pod != nil {
+		if pod.RunAsNonRoot != nil {
+			s.runAsNonRoot = *pod.RunAsNonRoot
+		}
+		if pod.SeccompProfile != nil {
+			s.seccompProfile = pod.SeccompProfile.Type
+		}
+	}
+
+	if sc == nil {
+		return s
+	}
+	if sc.Privileged != nil {
+		s.privileged = *sc.Privileged
+	}
+	if sc.RunAsNonRoot != nil {
+		s.runAsNonRoot = *sc.RunAsNonRoot
+	}
+	if sc.ReadOnlyRootFilesystem != nil {
+		s.readOnlyRootFilesystem = *sc.ReadOnlyRootFilesystem
+	}
+	if sc.Capabilities != nil {
+		s.dropsAllCapabilities = slices.Contains(sc.Capabilities.Drop, "ALL") && len(sc.Capabilities.Add) == 0
+	}
+	if sc.SeccompProfile != nil {
+		s.seccompProfile = sc.SeccompProfile.Type
+	}
+
+	return s
+}
+
+func TestSecurityContexts(t *testing.T) {
+	config := &ManifestConfig{Namespace: "kube-system", Snapshots: true}
+
+	seedDeployment, err := GenerateSeedControllerDeployment(config, "generic-token-kubeconfig")
+	if err != nil {
+		t.Fatalf("failed to generate seed controller deployment: %v", err)
+	}
+
+	pods := map[string]*corev1.PodSpec{
+		"controller":      &GenerateControllerDeployment(config).Spec.Template.Spec,
+		"seed-controller": &seedDeployment.Spec.Template.Spec,
+		"node":            &GenerateNodeDaemonSet(config).Spec.Template.Spec,
+	}
+
+	restricted := containerSecurity{
+		runAsNonRoot:           true,
+		readOnlyRootFilesystem: true,
+		dropsAllCapabilities:   true,
+		seccompProfile:         corev1.SeccompProfileTypeRuntimeDefault,
+	}
+	// the node sidecars run as root to create their sockets in the kubelet's host directories
+	nodeSidecar := containerSecurity{
+		readOnlyRootFilesystem: true,
+		dropsAllCapabilities:   true,
+		seccompProfile:         corev1.SeccompProfileTypeRuntimeDefault,
+	}
+	// the node driver logs into iSCSI targets and mounts volumes on the host
+	nodeDriver := containerSecurity{
+		privileged: true,
+	}
+
+	tests := []struct {
+		pod       string
+		container string
+		want      containerSecurity
+	}{
+		{pod: "controller", container: "synology-csi-driver", want: restricted},
+		{pod: "controller", container: "csi-provisioner", want: restricted},
+		{pod: "controller", container: "csi-attacher", want: restricted},
+		{pod: "controller", container: "csi-resizer", want: restricted},
+		{pod: "controller", container: "csi-snapshotter", want: restricted},
+		{pod: "controller", container: "liveness-probe", want: restricted},
+		{pod: "seed-controller", container: "synology-csi-driver", want: restricted},
+		{pod: "seed-controller", container: "csi-provisioner", want: restricted},
+		{pod: "seed-controller", container: "csi-attacher", want: restricted},
+		{pod: "seed-controller", container: "csi-resizer", want: restricted},
+		{pod: "seed-controller", container: "csi-snapshotter", want: restricted},
+		{pod: "seed-controller", container: "liveness-probe", want: restricted},
+		{pod: "node", container: "synology-csi-driver", want: nodeDriver},
+		{pod: "node", container: "csi-node-driver-registrar", want: nodeSidecar},
+		{pod: "node", container: "liveness-probe", want: nodeSidecar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pod+"/"+tt.container, func(t *testing.T) {
+			pod := pods[tt.pod]
+
+			i := slices.IndexFunc(pod.Containers, func(c corev1.Container) bool { return c.Name == tt.container })
+			if i < 0 {
+				t.Fatalf("container %s not found", tt.container)
+			}
+
+			if got := effectiveSecurity(pod.SecurityContext, pod.Containers[i].SecurityContext); got != tt.want {
+				t.Errorf("effective security context = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// every container must be covered by the table
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[tt.pod+"/"+tt.container] = true
+	}
+	for name, pod := range pods {
+		for _, c := range pod.Containers {
+			if !covered[name+"/"+c.Name] {
+				t.Errorf("container %s of %s is not covered", c.Name, name)
+			}
+		}
+	}
+}
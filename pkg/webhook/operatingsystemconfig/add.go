@@ -0,0 +1,41 @@
+package operatingsystemconfig
+
+import (
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/metal-stack/gardener-extension-csi-driver-synology/pkg/constants"
+)
+
+const (
+	// WebhookName is the name of the OperatingSystemConfig webhook
+	WebhookName = "operatingsystemconfig"
+	// WebhookPath is the path the OperatingSystemConfig webhook is served at
+	WebhookPath = "/webhooks/operatingsystemconfig"
+)
+
+var logger = log.Log.WithName("operatingsystemconfig-webhook")
+
+// AddToManager creates the webhook which prepares the worker nodes of shoots using this extension for iSCSI
+func AddToManager(mgr manager.Manager) (*extensionswebhook.Webhook, error) {
+	logger.Info("Adding webhook to manager")
+
+	return extensionswebhook.New(mgr, extensionswebhook.Args{
+		Provider: constants.ExtensionType,
+		Name:     WebhookName,
+		Path:     WebhookPath,
+		Target:   extensionswebhook.TargetSeed,
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				v1beta1constants.LabelExtensionExtensionTypePrefix + constants.ExtensionType: "true",
+			},
+		},
+		Mutators: map[extensionswebhook.Mutator][]extensionswebhook.Type{
+			NewMutator(logger): {{Obj: &extensionsv1alpha1.OperatingSystemConfig{}}},
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package operatingsystemconfig
+
+import (
+	"context"
+	"fmt"
+
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ISCSISetupUnitName is the name of the systemd unit preparing the iSCSI initiator on the node
+	ISCSISetupUnitName = "synology-iscsi-setup.service"
+	// ISCSISetupScriptPath is the path of the script preparing the iSCSI initiator on the node
+	ISCSISetupScriptPath = "/opt/bin/synology-iscsi-setup.sh"
+)
+
+// installCommands maps the supported operating system flavors to the command installing open-iscsi,
+// an empty command means that the flavor ships the initiator tools already.
+var installCommands = map[string]string{
+	"ubuntu":      "apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -y -qq --no-install-recommends open-iscsi",
+	"debian":      "apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -y -qq --no-install-recommends open-iscsi",
+	"gardenlinux": "apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -y -qq --no-install-recommends open-iscsi",
+	"suse-chost":  "zypper --non-interactive install --no-recommends open-iscsi",
+	"flatcar":     "",
+}
+
+// the initiator name is derived from the machine id so that it is unique per node and stable across reboots,
+// images with a baked-in initiator name get a fresh one on first boot
+const iscsiSetupScriptTemplate = `#!/bin/bash
+set -o errexit
+set -o pipefail
+
+if ! command -v iscsiadm >/dev/null 2>&1; then
+  echo "installing open-iscsi"
+  %s
+fi
+
+initiator_name="InitiatorName=iqn.2005-03.org.open-iscsi:$(cut -c1-12 /etc/machine-id)"
+if [[ "$(grep -s '^InitiatorName=' /etc/iscsi/initiatorname.iscsi)" != "${initiator_name}" ]]; then
+  echo "writing iscsi initiator name"
+  mkdir -p /etc/iscsi
+  echo "${initiator_name}" > /etc/iscsi/initiatorname.iscsi
+  chmod 0600 /etc/iscsi/initiatorname.iscsi
+  systemctl try-restart iscsid.service
+fi
+
+systemctl enable --now iscsid.service
+`
+
+const iscsiSetupUnit = `[Unit]
+Description=Prepare the iSCSI initiator for the Synology CSI driver
+Wants=network-online.target
+After=network-online.target
+Before=kubelet.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=` + ISCSISetupScriptPath + `
+
+[Install]
+WantedBy=multi-user.target
+`
+
+type mutator struct {
+	logger logr.Logger
+}
+
+// NewMutator returns a mutator adding the iSCSI initiator setup to OperatingSystemConfigs of supported flavors
+func NewMutator(logger logr.Logger) extensionswebhook.Mutator {
+	return &mutator{
+		logger: logger.WithName("mutator"),
+	}
+}
+
+// Mutate adds the iSCSI initiator setup script and unit to the given OperatingSystemConfig
+func (m *mutator) Mutate(_ context.Context, new, _ client.Object) error {
+	if new.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	osc, ok := new.(*extensionsv1alpha1.OperatingSystemConfig)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T, expected an OperatingSystemConfig", new)
+	}
+
+	// files and units of the provision purpose only run once during bootstrap, the node agent applies the reconcile ones
+	if osc.Spec.Purpose != extensionsv1alpha1.OperatingSystemConfigPurposeReconcile {
+		return nil
+	}
+
+	installCommand, supported := installCommands[osc.Spec.Type]
+	if !supported {
+		m.logger.Info("operating system flavor is not supported, skipping iscsi initiator setup", "type", osc.Spec.Type, "namespace", osc.Namespace, "name", osc.Name)
+		return nil
+	}
+
+	extensionswebhook.LogMutation(m.logger, osc.Kind, osc.Namespace, osc.Name)
+
+	if installCommand == "" {
+		installCommand = `echo "open-iscsi is not available on this node and cannot be installed" >&2; exit 1`
+	}
+
+	osc.Spec.Files = extensionswebhook.EnsureFileWithPath(osc.Spec.Files, extensionsv1alpha1.File{
+		Path:        ISCSISetupScriptPath,
+		Permissions: ptr.To[uint32](0o755),
+		Content: extensionsv1alpha1.FileContent{
+			Inline: &extensionsv1alpha1.FileContentInline{
+				Data: fmt.Sprintf(iscsiSetupScriptTemplate, installCommand),
+			},
+		},
+	})
+
+	osc.Spec.Units = extensionswebhook.EnsureUnitWithName(osc.Spec.Units, extensionsv1alpha1.Unit{
+		Name:      ISCSISetupUnitName,
+		Command:   ptr.To(extensionsv1alpha1.CommandStart),
+		Enable:    ptr.To(true),
+		Content:   ptr.To(iscsiSetupUnit),
+		FilePaths: []string{ISCSISetupScriptPath},
+	})
+
+	return nil
+}